@@ -1,23 +1,38 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"taskmango/apisvc/internal/config"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/notifiers"
+	"taskmango/apisvc/internal/repositories"
 	"taskmango/apisvc/internal/routes"
+	"taskmango/apisvc/internal/scheduler"
+	"taskmango/apisvc/internal/storage"
+	"taskmango/apisvc/internal/webhooks"
+	"time"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
-	
+
 	// Initialize database
 	db, err := config.InitDB(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Initialize attachment storage
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+
 	// Setup routes with auth middleware
-	router := routes.SetupRouter(db, cfg)
+	router := routes.SetupRouter(db, cfg, store)
 
 	// Start main server
 	go func() {
@@ -27,10 +42,57 @@ func main() {
 		}
 	}()
 
+	// Start reminder scheduler
+	reminderScheduler := scheduler.NewReminderScheduler(
+		repositories.NewReminderRepository(db),
+		repositories.NewTaskRepository(db),
+		map[models.ReminderChannel]notifiers.Notifier{
+			models.ChannelWebhook: notifiers.NewWebhookNotifier(10 * time.Second),
+			models.ChannelEmail:   notifiers.NewSMTPNotifier(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword),
+		},
+		time.Duration(cfg.ReminderPollInterval)*time.Second,
+		time.Duration(cfg.ReminderLease)*time.Second,
+	)
+	go reminderScheduler.Run(context.Background())
+	log.Printf("Reminder scheduler started, polling every %ds", cfg.ReminderPollInterval)
+
+	// Start webhook delivery worker
+	webhookWorker := scheduler.NewWebhookWorker(
+		repositories.NewWebhookRepository(db),
+		webhooks.NewSender(10*time.Second),
+		time.Duration(cfg.WebhookPollInterval)*time.Second,
+		time.Duration(cfg.WebhookLease)*time.Second,
+	)
+	go webhookWorker.Run(context.Background())
+	log.Printf("Webhook worker started, polling every %ds", cfg.WebhookPollInterval)
+
+	// Start trash purger
+	trashPurger := scheduler.NewTrashPurger(
+		repositories.NewTaskRepository(db),
+		repositories.NewAttachmentRepository(db),
+		store,
+		time.Duration(cfg.TrashRetentionDays)*24*time.Hour,
+		time.Duration(cfg.TrashPurgeInterval)*time.Second,
+	)
+	go trashPurger.Run(context.Background())
+	log.Printf("Trash purger started, keeping deleted tasks for %d days", cfg.TrashRetentionDays)
+
 	// Start probe server
 	probeRouter := routes.SetupProbeRouter(db)
 	log.Printf("Health check service started on port %d", cfg.ProbePort)
 	if err := probeRouter.Run(cfg.ProbeAddress()); err != nil {
 		log.Fatalf("Failed to start probe server: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// openStore creates the blob store for attachments chosen by STORAGE_BACKEND.
+func openStore(cfg *config.Config) (storage.Store, error) {
+	switch cfg.StorageBackend {
+	case "local":
+		return storage.NewLocalStore(cfg.StorageLocalDir)
+	case "s3":
+		return storage.NewS3Store(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey, 30*time.Second)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,52 @@
+// Package authz decides what a user may do with a task. Tasks outside any
+// project belong to the user who created them alone; tasks in a project are
+// open to its members according to their role.
+package authz
+
+import (
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+type Authorizer struct {
+	taskRepo    *repositories.TaskRepository
+	projectRepo *repositories.ProjectRepository
+}
+
+func NewAuthorizer(taskRepo *repositories.TaskRepository, projectRepo *repositories.ProjectRepository) *Authorizer {
+	return &Authorizer{taskRepo: taskRepo, projectRepo: projectRepo}
+}
+
+// TaskRole returns the role the user holds on a task. A task the user cannot
+// see at all is reported as gorm.ErrRecordNotFound.
+func (a *Authorizer) TaskRole(taskID uint, userID uint) (models.ProjectRole, error) {
+	task, err := a.taskRepo.FindByID(taskID, userID)
+	if err != nil {
+		return "", err
+	}
+	return a.RoleOf(task, userID)
+}
+
+// RoleOf returns the role the user holds on a task that was already loaded.
+// The creator of a task outside any project owns it; anyone else has no role.
+func (a *Authorizer) RoleOf(task *models.Task, userID uint) (models.ProjectRole, error) {
+	if task.ProjectID == nil {
+		if task.UserID == userID {
+			return models.RoleOwner, nil
+		}
+		return "", nil
+	}
+	return a.ProjectRole(*task.ProjectID, userID)
+}
+
+// ProjectRole returns the role the user holds in a project, or no role when
+// they are not a member.
+func (a *Authorizer) ProjectRole(projectID uint, userID uint) (models.ProjectRole, error) {
+	role, err := a.projectRepo.FindRole(projectID, userID)
+	if err == gorm.ErrRecordNotFound {
+		return "", nil
+	}
+	return role, err
+}
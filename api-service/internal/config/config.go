@@ -13,17 +13,36 @@ import (
 )
 
 type Config struct {
-	DBHost         string
-	DBPort         int
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	JWTIssuer      string
-	JWTAudience    string
-	JWTSigningKey  string
-	JWTValidity    int
-	APIPort        int
-	ProbePort      int
+	DBHost               string
+	DBPort               int
+	DBUser               string
+	DBPassword           string
+	DBName               string
+	JWTIssuer            string
+	JWTAudience          string
+	JWTSigningKey        string
+	JWTValidity          int
+	APIPort              int
+	ProbePort            int
+	ReminderPollInterval int
+	ReminderLease        int
+	SMTPHost             string
+	SMTPPort             int
+	SMTPFrom             string
+	SMTPUsername         string
+	SMTPPassword         string
+	WebhookPollInterval  int
+	WebhookLease         int
+	TrashRetentionDays   int
+	TrashPurgeInterval   int
+	StorageBackend       string
+	StorageLocalDir      string
+	S3Endpoint           string
+	S3Bucket             string
+	S3Region             string
+	S3AccessKey          string
+	S3SecretKey          string
+	AttachmentMaxSize    int
 }
 
 func (c *Config) APIAddress() string {
@@ -37,7 +56,7 @@ func (c *Config) ProbeAddress() string {
 func LoadConfig() *Config {
 
 	viper.SetConfigFile(".env")
-	_ = viper.ReadInConfig() 
+	_ = viper.ReadInConfig()
 
 	viper.AutomaticEnv()
 
@@ -51,17 +70,36 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		DBHost:        getEnv("DB_HOST", "localhost"),
-		DBPort:        getInt("DB_PORT", 3306),
-		DBUser:        getEnv("DB_USER", "root"),
-		DBPassword:    getEnv("DB_PASSWORD", ""),
-		DBName:        getEnv("DB_NAME", "taskmango_db"),
-		JWTIssuer:     getEnv("JWT_ISSUER", "auth-service"),
-		JWTAudience:   getEnv("JWT_AUDIENCE", "task-manager"),
-		JWTSigningKey: getEnv("JWT_SIGNING_KEY", "secret-key-change-me"),
-		JWTValidity:   getInt("JWT_VALIDITY", 3600),
-		APIPort:       getInt("API_PORT", 8080),
-		ProbePort:     getInt("PROBE_PORT", 8081),
+		DBHost:               getEnv("DB_HOST", "localhost"),
+		DBPort:               getInt("DB_PORT", 3306),
+		DBUser:               getEnv("DB_USER", "root"),
+		DBPassword:           getEnv("DB_PASSWORD", ""),
+		DBName:               getEnv("DB_NAME", "taskmango_db"),
+		JWTIssuer:            getEnv("JWT_ISSUER", "auth-service"),
+		JWTAudience:          getEnv("JWT_AUDIENCE", "task-manager"),
+		JWTSigningKey:        getEnv("JWT_SIGNING_KEY", "secret-key-change-me"),
+		JWTValidity:          getInt("JWT_VALIDITY", 3600),
+		APIPort:              getInt("API_PORT", 8080),
+		ProbePort:            getInt("PROBE_PORT", 8081),
+		ReminderPollInterval: getInt("REMINDER_POLL_INTERVAL", 30),
+		ReminderLease:        getInt("REMINDER_LEASE", 60),
+		SMTPHost:             getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:             getInt("SMTP_PORT", 1025),
+		SMTPFrom:             getEnv("SMTP_FROM", "reminders@taskmango.local"),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		WebhookPollInterval:  getInt("WEBHOOK_POLL_INTERVAL", 10),
+		WebhookLease:         getInt("WEBHOOK_LEASE", 60),
+		TrashRetentionDays:   getInt("TRASH_RETENTION_DAYS", 30),
+		TrashPurgeInterval:   getInt("TRASH_PURGE_INTERVAL", 3600),
+		StorageBackend:       getEnv("STORAGE_BACKEND", "local"),
+		StorageLocalDir:      getEnv("STORAGE_LOCAL_DIR", "./data/attachments"),
+		S3Endpoint:           getEnv("S3_ENDPOINT", ""),
+		S3Bucket:             getEnv("S3_BUCKET", ""),
+		S3Region:             getEnv("S3_REGION", "us-east-1"),
+		S3AccessKey:          getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:          getEnv("S3_SECRET_KEY", ""),
+		AttachmentMaxSize:    getInt("ATTACHMENT_MAX_SIZE", 10485760),
 	}
 }
 
@@ -95,4 +133,4 @@ func InitDB(cfg *Config) (*gorm.DB, error) {
 
 	log.Println("Connected to MySQL database")
 	return db, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkAssignees looks up the users a task is to be assigned to by username,
+// writing the error response when one has no account or could not see the
+// task. Assignees are returned in the order asked for, without repeats.
+func (c *TaskController) checkAssignees(ctx *gin.Context, task *models.Task, assignees []models.User) ([]models.User, bool) {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, assignee := range assignees {
+		username := strings.TrimSpace(assignee.Username)
+		if username == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Assignee usernames cannot be empty"})
+			return nil, false
+		}
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	if len(usernames) == 0 {
+		return []models.User{}, true
+	}
+
+	users, err := c.userRepo.FindByUsernames(usernames)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving users"})
+		return nil, false
+	}
+	byName := make(map[string]models.User, len(users))
+	for _, user := range users {
+		byName[user.Username] = user
+	}
+
+	resolved := make([]models.User, len(usernames))
+	for i, username := range usernames {
+		user, ok := byName[username]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("User %s not found", username)})
+			return nil, false
+		}
+		// An assignment nobody can act on is a mistake
+		role, err := c.authorizer.RoleOf(task, user.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving project"})
+			return nil, false
+		}
+		if role == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("User %s cannot see this task", username)})
+			return nil, false
+		}
+		resolved[i] = user
+	}
+	return resolved, true
+}
+
+// parseAssigneeValues reads a list of assignees given either as usernames or
+// as user objects.
+func parseAssigneeValues(raw json.RawMessage) ([]models.User, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	assignees := make([]models.User, len(values))
+	for i, value := range values {
+		if err := json.Unmarshal(value, &assignees[i].Username); err != nil {
+			if err := json.Unmarshal(value, &assignees[i]); err != nil {
+				return nil, err
+			}
+		}
+		if strings.TrimSpace(assignees[i].Username) == "" {
+			return nil, errors.New("empty username")
+		}
+	}
+	return assignees, nil
+}
+
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
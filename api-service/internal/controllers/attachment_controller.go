@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// multipartOverhead allows for the form framing around an uploaded file
+	multipartOverhead = 64 << 10
+	// sniffLength is how much of a file http.DetectContentType looks at
+	sniffLength = 512
+)
+
+// inlineTypes are shown in the browser when downloaded; anything else is
+// offered as a download so that uploaded HTML or scripts never run.
+var inlineTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type AttachmentController struct {
+	attachmentRepo *repositories.AttachmentRepository
+	taskRepo       *repositories.TaskRepository
+	store          storage.Store
+	maxSize        int64
+}
+
+func NewAttachmentController(attachmentRepo *repositories.AttachmentRepository, taskRepo *repositories.TaskRepository, store storage.Store,
+	maxSize int64) *AttachmentController {
+	return &AttachmentController{attachmentRepo: attachmentRepo, taskRepo: taskRepo, store: store, maxSize: maxSize}
+}
+
+func (c *AttachmentController) GetAttachments(ctx *gin.Context) {
+	task, _, ok := c.task(ctx)
+	if !ok {
+		return
+	}
+
+	attachments, err := c.attachmentRepo.FindByTaskID(task.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attachments"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment stores the multipart form field "file" as an attachment
+// of the task. The content type is sniffed from the file itself.
+func (c *AttachmentController) UploadAttachment(ctx *gin.Context) {
+	task, userID, ok := c.task(ctx)
+	if !ok {
+		return
+	}
+
+	tooLarge := fmt.Sprintf("File is larger than %d bytes", c.maxSize)
+	if ctx.Request.ContentLength > c.maxSize+multipartOverhead {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": tooLarge})
+		return
+	}
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxSize+multipartOverhead)
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": tooLarge})
+		} else {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		}
+		return
+	}
+	if header.Size > c.maxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": tooLarge})
+		return
+	}
+	if header.Size == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+	filename := attachmentFilename(header.Filename)
+	contentType := sniffContentType(head[:n], filename)
+
+	key, err := attachmentKey(task.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing attachment"})
+		return
+	}
+	body := io.MultiReader(bytes.NewReader(head[:n]), file)
+	if err := c.store.Put(ctx.Request.Context(), key, body, header.Size, contentType); err != nil {
+		log.Printf("Failed to store attachment of task %d: %v", task.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error storing attachment"})
+		return
+	}
+
+	attachment, err := c.attachmentRepo.Create(models.Attachment{
+		TaskID:      task.ID,
+		UserID:      userID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        header.Size,
+		StorageKey:  key,
+	})
+	if err != nil {
+		if err := c.removeBlob(ctx, key); err != nil {
+			log.Printf("Failed to delete orphaned blob %s: %v", key, err)
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating attachment"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, attachment)
+}
+
+func (c *AttachmentController) DownloadAttachment(ctx *gin.Context) {
+	attachment, ok := c.attachment(ctx)
+	if !ok {
+		return
+	}
+
+	blob, err := c.store.Get(ctx.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Attachment file not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attachment"})
+		}
+		return
+	}
+	defer blob.Close()
+
+	disposition := "attachment"
+	if inlineTypes[attachment.ContentType] {
+		disposition = "inline"
+	}
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, blob, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=3600",
+	})
+}
+
+func (c *AttachmentController) DeleteAttachment(ctx *gin.Context) {
+	attachment, ok := c.attachment(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.attachmentRepo.Delete(attachment.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting attachment"})
+		return
+	}
+	if err := c.removeBlob(ctx, attachment.StorageKey); err != nil {
+		log.Printf("Failed to delete blob %s of attachment %d: %v", attachment.StorageKey, attachment.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// removeBlob deletes the blob of an attachment that is gone, or never came to
+// be. It goes ahead when the client hangs up meanwhile, so that no blob is
+// left behind without an attachment.
+func (c *AttachmentController) removeBlob(ctx *gin.Context, key string) error {
+	return c.store.Delete(context.WithoutCancel(ctx.Request.Context()), key)
+}
+
+// task loads the task named in the path, writing the error response when it
+// does not exist or belongs to someone else.
+func (c *AttachmentController) task(ctx *gin.Context) (*models.Task, uint, bool) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return nil, 0, false
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return nil, userID, false
+	}
+
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return nil, userID, false
+	}
+	return task, userID, true
+}
+
+// attachment loads the attachment named in the path, writing the error
+// response when it is not an attachment of the user's task.
+func (c *AttachmentController) attachment(ctx *gin.Context) (*models.Attachment, bool) {
+	task, _, ok := c.task(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	attachmentID, err := strconv.Atoi(ctx.Param("attachmentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return nil, false
+	}
+
+	attachment, err := c.attachmentRepo.FindByID(uint(attachmentID), task.ID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving attachment"})
+		}
+		return nil, false
+	}
+	return attachment, true
+}
+
+// attachmentKey picks a fresh storage key for a file of taskID. Keys are
+// random so that they never depend on user-supplied names.
+func attachmentKey(taskID uint) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(random)), nil
+}
+
+// attachmentFilename reduces a client-supplied file name to its base name
+// without control characters.
+func attachmentFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:])
+	}
+	return name
+}
+
+// sniffContentType determines the content type from the start of a file.
+// Only when the contents are not recognized does the file extension decide.
+func sniffContentType(head []byte, filename string) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/octet-stream" {
+		if byExtension := mime.TypeByExtension(filepath.Ext(filename)); byExtension != "" {
+			contentType = byExtension
+		}
+	}
+	return contentType
+}
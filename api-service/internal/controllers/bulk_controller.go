@@ -0,0 +1,526 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxBulkItems = 1000
+
+const (
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+var errTooManyTasks = errors.New("too many tasks")
+
+type BulkChanges struct {
+	Status       models.TaskStatus   `json:"status"`
+	Priority     models.TaskPriority `json:"priority"`
+	DueDate      *time.Time          `json:"due_date"`
+	ClearDueDate bool                `json:"clear_due_date"`
+	ProjectID    *uint               `json:"project_id"`
+	ClearProject bool                `json:"clear_project"`
+	AddTags      []string            `json:"add_tags"`
+	RemoveTags   []string            `json:"remove_tags"`
+}
+
+// BulkRequest selects tasks either by ID or by filter, never both. Versions
+// maps task IDs to the versions the client has seen, the way If-Match does
+// for a single task; a task found at another version is left alone.
+type BulkRequest struct {
+	Action   string             `json:"action" binding:"required"`
+	IDs      []uint             `json:"ids"`
+	Filter   *models.TaskFilter `json:"filter"`
+	Changes  BulkChanges        `json:"changes"`
+	Versions map[uint]uint      `json:"versions"`
+}
+
+type BulkItemResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkResult struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// pendingEvent is a webhook event held back until the transaction that
+// caused it has committed.
+type pendingEvent struct {
+	event string
+	data  interface{}
+}
+
+// BulkTasks applies one update or deletion to many tasks in a single
+// transaction. Tasks that cannot take the change, because they do not exist
+// or would be left invalid, are reported and skipped; any other error rolls
+// the whole batch back. ?force=true and ?children= work as for UpdateTask and
+// DeleteTask.
+func (c *TaskController) BulkTasks(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	var bulkReq BulkRequest
+	if err := ctx.ShouldBindJSON(&bulkReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bulk data"})
+		return
+	}
+	if !checkBulkRequest(ctx, &bulkReq) {
+		return
+	}
+	if projectID := bulkReq.Changes.ProjectID; projectID != nil && !c.checkProject(ctx, *projectID, userID) {
+		return
+	}
+
+	force := ctx.Query("force") == "true"
+	children := ctx.DefaultQuery("children", "reparent")
+	if children != "reparent" && children != "cascade" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid children mode"})
+		return
+	}
+
+	var result BulkResult
+	var events []pendingEvent
+	err := c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		txc := c.withTx(tx)
+		result, events = BulkResult{Results: []BulkItemResult{}}, nil
+
+		tasks, missing, err := txc.bulkSelection(userID, bulkReq)
+		if err != nil {
+			return err
+		}
+		for _, id := range missing {
+			result.Results = append(result.Results, BulkItemResult{ID: id, Status: "not_found", Error: "Task not found"})
+		}
+
+		// Versions are compared as selected, before the batch changes them
+		stale := make(map[uint]bool)
+		for _, task := range tasks {
+			if version, ok := bulkReq.Versions[task.ID]; ok && version != task.Version {
+				stale[task.ID] = true
+			}
+		}
+
+		deleted := make(map[uint]bool)
+		carried := make(map[uint]bool)
+		roles := make(map[uint]models.ProjectRole)
+		for i := range tasks {
+			task := &tasks[i]
+			editable, err := c.canEdit(task, userID, roles)
+			if err != nil {
+				return err
+			}
+			if !editable {
+				result.Results = append(result.Results, BulkItemResult{ID: task.ID, Status: "failed", Error: "Permission denied"})
+				continue
+			}
+			if deleted[task.ID] {
+				// Already gone with an ancestor deleted earlier in the batch
+				result.Results = append(result.Results, BulkItemResult{ID: task.ID, Status: "deleted"})
+				continue
+			}
+			if stale[task.ID] {
+				result.Results = append(result.Results, BulkItemResult{ID: task.ID, Status: "failed", Error: "Task has been modified"})
+				continue
+			}
+			if carried[task.ID] {
+				// Already moved to the new project with an ancestor earlier in the batch
+				if task, err = txc.taskRepo.FindByID(task.ID, userID); err != nil {
+					return err
+				}
+			}
+
+			var failure string
+			var taskEvents []pendingEvent
+			if bulkReq.Action == BulkDelete {
+				taskEvents, failure, err = txc.bulkDelete(userCtx, task, children == "cascade", deleted)
+			} else {
+				taskEvents, failure, err = txc.bulkUpdate(userCtx, task, bulkReq.Changes, force, carried)
+			}
+			if err != nil {
+				return err
+			}
+
+			if failure != "" {
+				result.Results = append(result.Results, BulkItemResult{ID: task.ID, Status: "failed", Error: failure})
+				continue
+			}
+			status := "updated"
+			if bulkReq.Action == BulkDelete {
+				status = "deleted"
+				if children != "cascade" {
+					// Subtasks later in the batch were moved up by the delete
+					for j := range tasks[i+1:] {
+						child := &tasks[i+1+j]
+						if child.ParentID != nil && *child.ParentID == task.ID {
+							child.ParentID = task.ParentID
+							child.Version++
+						}
+					}
+				}
+			}
+			result.Results = append(result.Results, BulkItemResult{ID: task.ID, Status: status})
+			events = append(events, taskEvents...)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errTooManyTasks) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Filter matches more than " + strconv.Itoa(maxBulkItems) + " tasks"})
+		} else if repositories.IsDuplicate(err) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Tasks conflict with a concurrent change"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error applying bulk operation"})
+		}
+		return
+	}
+
+	for _, event := range events {
+		c.dispatcher.Emit(userID, event.event, event.data)
+	}
+
+	for _, item := range result.Results {
+		if item.Status == "updated" || item.Status == "deleted" {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// withTx returns a copy of the controller whose repositories all work inside tx.
+func (c *TaskController) withTx(tx *gorm.DB) *TaskController {
+	return &TaskController{
+		taskRepo:       c.taskRepo.WithTx(tx),
+		tagRepo:        c.tagRepo.WithTx(tx),
+		dependencyRepo: c.dependencyRepo.WithTx(tx),
+		reminderRepo:   c.reminderRepo.WithTx(tx),
+		historyRepo:    c.historyRepo.WithTx(tx),
+		projectRepo:    c.projectRepo.WithTx(tx),
+		workflowRepo:   c.workflowRepo.WithTx(tx),
+		userRepo:       c.userRepo,
+		authorizer:     c.authorizer,
+		dispatcher:     c.dispatcher,
+	}
+}
+
+// canEdit reports whether the user may change task, looking project roles up
+// once per project through roles.
+func (c *TaskController) canEdit(task *models.Task, userID uint, roles map[uint]models.ProjectRole) (bool, error) {
+	if task.ProjectID != nil {
+		if role, checked := roles[*task.ProjectID]; checked {
+			return role.Includes(models.RoleEditor), nil
+		}
+	}
+	role, err := c.authorizer.RoleOf(task, userID)
+	if err != nil {
+		return false, err
+	}
+	if task.ProjectID != nil {
+		roles[*task.ProjectID] = role
+	}
+	return role.Includes(models.RoleEditor), nil
+}
+
+// bulkSelection loads the tasks a bulk request applies to, along with the
+// requested IDs that are not tasks of the user.
+func (c *TaskController) bulkSelection(userID uint, bulkReq BulkRequest) ([]models.Task, []uint, error) {
+	if bulkReq.Filter != nil {
+		tasks, err := c.taskRepo.FindMatching(userID, *bulkReq.Filter, maxBulkItems+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tasks) > maxBulkItems {
+			return nil, nil, errTooManyTasks
+		}
+		return tasks, nil, nil
+	}
+
+	var tasks []models.Task
+	var missing []uint
+	for _, id := range bulkReq.IDs {
+		task, err := c.taskRepo.FindByID(id, userID)
+		if err == gorm.ErrRecordNotFound {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	return tasks, missing, nil
+}
+
+// bulkUpdate applies changes to one task the way UpdateTask would, recording
+// the subtasks that moved projects with it in carried. A non-empty failure
+// explains why the task was left alone.
+func (c *TaskController) bulkUpdate(actor middlewares.RequestContext, task *models.Task, changes BulkChanges, force bool,
+	carried map[uint]bool) ([]pendingEvent, string, error) {
+	previous := *task
+	previousTags := task.Tags
+	if changes.Status != "" {
+		task.Status = changes.Status
+	}
+	if changes.Priority != "" {
+		task.Priority = changes.Priority
+	}
+	if changes.DueDate != nil {
+		task.DueDate = changes.DueDate
+	}
+	if changes.ClearDueDate {
+		task.DueDate = nil
+	}
+	if changes.ProjectID != nil {
+		task.ProjectID = changes.ProjectID
+	}
+	if changes.ClearProject {
+		if task.ProjectID != nil && task.UserID != actor.UserID {
+			return nil, errLeaveProject, nil
+		}
+		task.ProjectID = nil
+	}
+	// A subtask only changes projects along with its parent
+	if task.ParentID != nil && !sameID(previous.ProjectID, task.ProjectID) {
+		parent, err := c.taskRepo.FindByID(*task.ParentID, actor.UserID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, "", err
+		}
+		if err != nil || !sameID(parent.ProjectID, task.ProjectID) {
+			return nil, errParentProject, nil
+		}
+	}
+	if err := task.Validate(); err != nil {
+		return nil, err.Error(), nil
+	}
+	if err := c.applyWorkflow(task, &previous); err != nil {
+		var taskErr *taskError
+		if errors.As(err, &taskErr) {
+			return nil, taskErr.message, nil
+		}
+		return nil, "", err
+	}
+
+	completing := task.Category == models.CategoryDone && previous.Category != models.CategoryDone
+	if completing && !force {
+		blockers, err := c.dependencyRepo.FindUnfinishedBlockers(task.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(blockers) > 0 {
+			return nil, "Task is blocked by unfinished tasks", nil
+		}
+	}
+	if err := normalizeRecurrence(task); err != nil {
+		return nil, err.Error(), nil
+	}
+
+	updatedTask, err := c.taskRepo.Update(*task)
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		return nil, "Task has been modified", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if changes.DueDate != nil || changes.ClearDueDate {
+		if err := c.reminderRepo.Reschedule(updatedTask.ID, updatedTask.DueDate); err != nil {
+			return nil, "", err
+		}
+	}
+	if !sameID(previous.ProjectID, updatedTask.ProjectID) {
+		ids, err := c.carrySubtasks(actor, updatedTask)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, id := range ids {
+			carried[id] = true
+		}
+	}
+
+	var events []pendingEvent
+	for _, name := range changes.AddTags {
+		tag, created, err := c.tagRepo.FindOrCreateByName(actor.UserID, name)
+		if err != nil {
+			return nil, "", err
+		}
+		if created {
+			events = append(events, pendingEvent{models.EventTagCreated, tag})
+		}
+		if slices.ContainsFunc(previousTags, func(t models.Tag) bool { return t.ID == tag.ID }) {
+			continue
+		}
+		if err := c.taskRepo.AddTag(updatedTask.ID, tag.ID); err != nil {
+			return nil, "", err
+		}
+		events = append(events, pendingEvent{models.EventTagAdded, gin.H{"task_id": updatedTask.ID, "tag": tag}})
+	}
+	for _, name := range changes.RemoveTags {
+		i := slices.IndexFunc(previousTags, func(t models.Tag) bool { return t.UserID == actor.UserID && t.Name == name })
+		if i < 0 {
+			continue
+		}
+		if err := c.taskRepo.RemoveTag(updatedTask.ID, previousTags[i].ID); err != nil {
+			return nil, "", err
+		}
+		events = append(events, pendingEvent{models.EventTagRemoved, gin.H{"task_id": updatedTask.ID, "tag": previousTags[i]}})
+	}
+
+	if updatedTask.Tags, err = c.tagRepo.FindByTaskID(updatedTask.ID); err != nil {
+		return nil, "", err
+	}
+	events = append([]pendingEvent{{models.EventTaskUpdated, updatedTask}}, events...)
+	history := append(models.DiffTask(previous, *updatedTask), models.DiffTags(previousTags, updatedTask.Tags)...)
+	if err := recordHistory(c.historyRepo, actor, updatedTask.ID, history...); err != nil {
+		return nil, "", err
+	}
+
+	if completing {
+		next, err := c.spawnNextOccurrence(updatedTask)
+		if err != nil {
+			return nil, "", err
+		}
+		if next != nil {
+			if err := recordHistory(c.historyRepo, actor, next.ID, models.TaskHistory{Action: models.HistoryCreated}); err != nil {
+				return nil, "", err
+			}
+			events = append(events, pendingEvent{models.EventTaskCreated, next})
+		}
+	}
+
+	return events, "", nil
+}
+
+// bulkDelete deletes one task the way DeleteTask would, recording every task
+// that goes with it in deleted. A non-empty failure explains why the task was
+// left alone.
+func (c *TaskController) bulkDelete(actor middlewares.RequestContext, task *models.Task, cascade bool, deleted map[uint]bool) ([]pendingEvent, string, error) {
+	gone := []models.Task{*task}
+	if cascade {
+		descendants, err := c.taskRepo.FindDescendants([]uint{task.ID}, actor.UserID)
+		if err != nil {
+			return nil, "", err
+		}
+		gone = append(gone, descendants...)
+	}
+
+	err := c.taskRepo.Delete(task.ID, task.Version, cascade)
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		return nil, "Task has been modified", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := make([]pendingEvent, len(gone))
+	for i := range gone {
+		deleted[gone[i].ID] = true
+		if err := recordHistory(c.historyRepo, actor, gone[i].ID, models.TaskHistory{Action: models.HistoryDeleted}); err != nil {
+			return nil, "", err
+		}
+		events[i] = pendingEvent{models.EventTaskDeleted, &gone[i]}
+	}
+	return events, "", nil
+}
+
+func checkBulkRequest(ctx *gin.Context, bulkReq *BulkRequest) bool {
+	if bulkReq.Action != BulkUpdate && bulkReq.Action != BulkDelete {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bulk action"})
+		return false
+	}
+
+	if (len(bulkReq.IDs) == 0) == (bulkReq.Filter == nil) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of ids and filter is required"})
+		return false
+	}
+	if len(bulkReq.IDs) > maxBulkItems {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "At most " + strconv.Itoa(maxBulkItems) + " ids are allowed"})
+		return false
+	}
+	slices.Sort(bulkReq.IDs)
+	bulkReq.IDs = slices.Compact(bulkReq.IDs)
+
+	if filter := bulkReq.Filter; filter != nil {
+		filter.Query = strings.TrimSpace(filter.Query)
+		if filter.ParentID != "" && filter.ParentID != "root" {
+			if _, err := strconv.Atoi(filter.ParentID); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+				return false
+			}
+		}
+		if filter.ProjectID != "" && filter.ProjectID != "none" {
+			if _, err := strconv.Atoi(filter.ProjectID); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+				return false
+			}
+		}
+		if filter.Category != "" && !filter.Category.Valid() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+			return false
+		}
+		if filter.Assignee != "" && filter.Assignee != "me" && filter.Assignee != "none" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee"})
+			return false
+		}
+	}
+
+	if bulkReq.Action == BulkDelete {
+		return true
+	}
+
+	changes := &bulkReq.Changes
+	switch changes.Priority {
+	case "", models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priority"})
+		return false
+	}
+	if changes.DueDate != nil && changes.ClearDueDate {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "due_date and clear_due_date cannot be combined"})
+		return false
+	}
+	if changes.ProjectID != nil && changes.ClearProject {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "project_id and clear_project cannot be combined"})
+		return false
+	}
+	for _, names := range [][]string{changes.AddTags, changes.RemoveTags} {
+		for i, name := range names {
+			names[i] = strings.TrimSpace(name)
+			if err := models.CheckTagName(names[i]); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return false
+			}
+		}
+	}
+	// A tag named twice would be added twice
+	slices.Sort(changes.AddTags)
+	changes.AddTags = slices.Compact(changes.AddTags)
+
+	if changes.Status == "" && changes.Priority == "" && changes.DueDate == nil && !changes.ClearDueDate &&
+		changes.ProjectID == nil && !changes.ClearProject && len(changes.AddTags) == 0 && len(changes.RemoveTags) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No changes given"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"taskmango/apisvc/internal/ical"
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const calendarProdID = "-//TaskMango//Task Feed//EN"
+
+type CalendarController struct {
+	feedRepo *repositories.CalendarFeedRepository
+	taskRepo *repositories.TaskRepository
+}
+
+func NewCalendarController(feedRepo *repositories.CalendarFeedRepository, taskRepo *repositories.TaskRepository) *CalendarController {
+	return &CalendarController{feedRepo: feedRepo, taskRepo: taskRepo}
+}
+
+func (c *CalendarController) GetFeed(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	feed, err := c.feedRepo.FindByUserID(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving calendar feed"})
+		}
+		return
+	}
+
+	// The URL is a credential, only CreateFeed hands it out
+	ctx.JSON(http.StatusOK, gin.H{"feed": feed})
+}
+
+// CreateFeed issues a new feed token, revoking the previous URL if there was
+// one. The response is the only place the new URL appears.
+func (c *CalendarController) CreateFeed(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	token := make([]byte, 24)
+	if _, err := rand.Read(token); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating calendar feed"})
+		return
+	}
+
+	feed, err := c.feedRepo.SetToken(userID, hex.EncodeToString(token))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating calendar feed"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"feed": feed, "token": feed.Token, "url": feedURL(ctx, feed.Token)})
+}
+
+func (c *CalendarController) DeleteFeed(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	if err := c.feedRepo.Delete(userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting calendar feed"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Calendar feed deleted successfully"})
+}
+
+// ServeFeed renders the tasks with a due date as an iCalendar document. It is
+// authenticated by the token in the path alone. Tasks are published as VTODO
+// unless ?component=event asks for VEVENT, which more calendar apps display;
+// ?tag=, ?status= and ?category= narrow the feed down.
+func (c *CalendarController) ServeFeed(ctx *gin.Context) {
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	component := ctx.DefaultQuery("component", "todo")
+	if component != "todo" && component != "event" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid component"})
+		return
+	}
+
+	filter := models.TaskFilter{
+		Status:   models.TaskStatus(ctx.Query("status")),
+		Category: models.StatusCategory(ctx.Query("category")),
+		TagName:  ctx.Query("tag"),
+	}
+	if filter.Category != "" && !filter.Category.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+		return
+	}
+
+	feed, err := c.feedRepo.FindByToken(token)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving calendar feed"})
+		}
+		return
+	}
+
+	tasks, err := c.taskRepo.FindWithDueDate(feed.UserID, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tasks"})
+		return
+	}
+
+	cal := ical.New(calendarProdID, "TaskMango")
+	for _, task := range tasks {
+		if component == "event" {
+			cal.Add(taskEvent(task))
+		} else {
+			cal.Add(taskTodo(task))
+		}
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=300")
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", cal.Bytes())
+}
+
+func feedURL(ctx *gin.Context, token string) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, ctx.Request.Host, token)
+}
+
+// taskProperties are shared by both renderings of a task. Occurrences of a
+// recurring task are separate tasks, so no RRULE is emitted.
+func taskProperties(task models.Task) []ical.Property {
+	properties := []ical.Property{
+		{Name: "UID", Value: fmt.Sprintf("task-%d@taskmango", task.ID)},
+		ical.DateTime("DTSTAMP", task.UpdatedAt),
+		ical.DateTime("CREATED", task.CreatedAt),
+		ical.DateTime("LAST-MODIFIED", task.UpdatedAt),
+		ical.Text("SUMMARY", task.Title),
+	}
+	if task.Description != "" {
+		properties = append(properties, ical.Text("DESCRIPTION", task.Description))
+	}
+	if len(task.Tags) > 0 {
+		names := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			names[i] = tag.Name
+		}
+		properties = append(properties, ical.TextList("CATEGORIES", names))
+	}
+
+	// RFC 5545 ranks 1 highest and 9 lowest
+	switch task.Priority {
+	case models.PriorityHigh:
+		properties = append(properties, ical.Property{Name: "PRIORITY", Value: "1"})
+	case models.PriorityMedium:
+		properties = append(properties, ical.Property{Name: "PRIORITY", Value: "5"})
+	case models.PriorityLow:
+		properties = append(properties, ical.Property{Name: "PRIORITY", Value: "9"})
+	}
+	return properties
+}
+
+func taskTodo(task models.Task) ical.Component {
+	todo := ical.Component{Name: "VTODO", Properties: taskProperties(task)}
+	todo.Add(ical.DateTime("DUE", *task.DueDate))
+
+	// Calendars only know the categories, not the statuses of a workflow
+	switch task.Category {
+	case models.CategoryTodo:
+		todo.Add(ical.Property{Name: "STATUS", Value: "NEEDS-ACTION"})
+	case models.CategoryDoing:
+		todo.Add(ical.Property{Name: "STATUS", Value: "IN-PROCESS"})
+	case models.CategoryDone:
+		todo.Add(ical.Property{Name: "STATUS", Value: "COMPLETED"})
+		todo.Add(ical.Property{Name: "PERCENT-COMPLETE", Value: "100"})
+	}
+	return todo
+}
+
+// taskEvent places the task on the calendar as a zero-length event at its
+// due time.
+func taskEvent(task models.Task) ical.Component {
+	event := ical.Component{Name: "VEVENT", Properties: taskProperties(task)}
+	event.Add(
+		ical.DateTime("DTSTART", *task.DueDate),
+		ical.DateTime("DTEND", *task.DueDate),
+		ical.Property{Name: "TRANSP", Value: "TRANSPARENT"},
+	)
+	return event
+}
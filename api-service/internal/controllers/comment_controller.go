@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CommentController struct {
+	commentRepo *repositories.CommentRepository
+	taskRepo    *repositories.TaskRepository
+}
+
+func NewCommentController(commentRepo *repositories.CommentRepository, taskRepo *repositories.TaskRepository) *CommentController {
+	return &CommentController{commentRepo: commentRepo, taskRepo: taskRepo}
+}
+
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// GetComments lists the comments of a task oldest first. The next page is
+// fetched by passing the returned next_cursor as ?cursor=.
+func (c *CommentController) GetComments(ctx *gin.Context) {
+	task, _, ok := c.task(ctx)
+	if !ok {
+		return
+	}
+
+	limit := defaultPageLimit
+	if value := ctx.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageLimit)
+	}
+	var afterID uint64
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		var err error
+		if afterID, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+	}
+
+	// One extra comment tells whether there is another page
+	comments, err := c.commentRepo.FindByTaskID(task.ID, uint(afterID), limit+1)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving comments"})
+		return
+	}
+	total, err := c.commentRepo.CountByTaskID(task.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting comments"})
+		return
+	}
+
+	page := models.CommentPage{Comments: comments}
+	if len(comments) > limit {
+		page.Comments = comments[:limit]
+		page.NextCursor = strconv.FormatUint(uint64(comments[limit-1].ID), 10)
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, page)
+}
+
+// CreateComment posts a comment in the name of the user the token was
+// issued to.
+func (c *CommentController) CreateComment(ctx *gin.Context) {
+	task, userCtx, ok := c.task(ctx)
+	if !ok {
+		return
+	}
+
+	var commentReq CommentRequest
+	if err := ctx.ShouldBindJSON(&commentReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment data"})
+		return
+	}
+
+	comment := models.TaskComment{
+		TaskID:   task.ID,
+		AuthorID: userCtx.UserID,
+		Author:   userCtx.Username,
+		Body:     strings.TrimSpace(commentReq.Body),
+	}
+	if err := comment.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdComment, err := c.commentRepo.Create(comment)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating comment"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createdComment)
+}
+
+// UpdateComment replaces the body of a comment. Only its author may edit it.
+func (c *CommentController) UpdateComment(ctx *gin.Context) {
+	comment, ok := c.ownComment(ctx)
+	if !ok {
+		return
+	}
+
+	var commentReq CommentRequest
+	if err := ctx.ShouldBindJSON(&commentReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment data"})
+		return
+	}
+
+	body := strings.TrimSpace(commentReq.Body)
+	if body == comment.Body {
+		ctx.JSON(http.StatusOK, comment)
+		return
+	}
+	edited := models.TaskComment{Body: body}
+	if err := edited.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.commentRepo.UpdateBody(comment, body, time.Now()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating comment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment removes a comment. Only its author may delete it.
+func (c *CommentController) DeleteComment(ctx *gin.Context) {
+	comment, ok := c.ownComment(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.commentRepo.Delete(comment.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting comment"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// task loads the task named in the path, writing the error response when it
+// does not exist or belongs to someone else.
+func (c *CommentController) task(ctx *gin.Context) (*models.Task, middlewares.RequestContext, bool) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return nil, middlewares.RequestContext{}, false
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return nil, userCtx, false
+	}
+
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return nil, userCtx, false
+	}
+	return task, userCtx, true
+}
+
+// ownComment loads the comment named in the path, writing the error response
+// unless it exists and was written by the current user.
+func (c *CommentController) ownComment(ctx *gin.Context) (*models.TaskComment, bool) {
+	task, userCtx, ok := c.task(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	commentID, err := strconv.Atoi(ctx.Param("commentId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return nil, false
+	}
+
+	comment, err := c.commentRepo.FindByID(uint(commentID), task.ID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving comment"})
+		}
+		return nil, false
+	}
+
+	if comment.AuthorID != userCtx.UserID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only the author can change a comment"})
+		return nil, false
+	}
+	return comment, true
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AddDependencyRequest struct {
+	DependsOnID uint `json:"depends_on_id" binding:"required"`
+}
+
+func (c *TaskController) GetDependencies(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// Verify task exists and belongs to user
+	if _, err := c.taskRepo.FindByID(uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	blockedBy, err := c.dependencyRepo.FindBlockerTasks(uint(taskID), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving dependencies"})
+		return
+	}
+
+	blocking, err := c.dependencyRepo.FindBlockingTasks(uint(taskID), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving dependencies"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.TaskDependencies{BlockedBy: blockedBy, Blocking: blocking})
+}
+
+func (c *TaskController) AddDependency(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var depReq AddDependencyRequest
+	if err := ctx.ShouldBindJSON(&depReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependency data"})
+		return
+	}
+
+	// Both ends of the dependency must belong to the user
+	if _, err := c.taskRepo.FindByID(uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+	if _, err := c.taskRepo.FindByID(depReq.DependsOnID, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Blocking task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	if err := c.dependencyRepo.Add(uint(taskID), depReq.DependsOnID); err != nil {
+		if errors.Is(err, repositories.ErrDependencyCycle) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Dependency would create a cycle"})
+		} else if errors.Is(err, repositories.ErrDependencyExists) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Dependency already exists"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error adding dependency"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.TaskDependency{TaskID: uint(taskID), DependsOnID: depReq.DependsOnID})
+}
+
+func (c *TaskController) RemoveDependency(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	dependsOnID, err := strconv.Atoi(ctx.Param("dependsOnId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dependency ID"})
+		return
+	}
+
+	// Verify task exists and belongs to user
+	if _, err := c.taskRepo.FindByID(uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	if err := c.dependencyRepo.Remove(uint(taskID), uint(dependsOnID)); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Dependency not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error removing dependency"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Dependency removed successfully"})
+}
+
+// withDependencies fills in blocked_by and blocking for every task in the
+// list, as far as userID can see the tasks at the other end.
+func (c *TaskController) withDependencies(userTasks []models.UserTask, userID uint) error {
+	ids := make([]uint, len(userTasks))
+	for i, userTask := range userTasks {
+		ids[i] = userTask.Task.ID
+	}
+
+	blockedBy, err := c.dependencyRepo.FindBlockedBy(ids, userID)
+	if err != nil {
+		return err
+	}
+	blocking, err := c.dependencyRepo.FindBlocking(ids, userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range userTasks {
+		userTasks[i].BlockedBy = blockedBy[userTasks[i].Task.ID]
+		userTasks[i].Blocking = blocking[userTasks[i].Task.ID]
+	}
+	return nil
+}
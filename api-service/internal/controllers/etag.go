@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskETag identifies one version of a task. Every write to a task bumps its
+// version, so the tag is a strong validator for the task's own fields.
+func taskETag(task *models.Task) string {
+	return fmt.Sprintf(`"%d-%d"`, task.ID, task.Version)
+}
+
+// etagMatches reports whether the entity tags listed in an If-Match or
+// If-None-Match header include etag. If-None-Match compares weakly, so a W/
+// prefix is ignored; If-Match compares strongly and weak tags never match.
+func etagMatches(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch verifies that the client changing task has seen its current
+// version, writing the error response when it has not. Changes without an
+// If-Match header are refused so that no client overwrites blindly.
+func checkIfMatch(ctx *gin.Context, task *models.Task) bool {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		ctx.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return false
+	}
+	if !etagMatches(header, taskETag(task), false) {
+		ctx.Header("ETag", taskETag(task))
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": "Task has been modified"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetHistory lists a task's activity, newest first. Older entries are paged
+// through with ?before=<id of the last entry seen>.
+func (c *TaskController) GetHistory(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	limit := defaultPageLimit
+	if value := ctx.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageLimit)
+	}
+	var before uint64
+	if value := ctx.Query("before"); value != "" {
+		before, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before"})
+			return
+		}
+	}
+
+	// Verify task exists and belongs to user
+	if _, err := c.taskRepo.FindByID(uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	entries, err := c.historyRepo.FindByTaskID(uint(taskID), uint(before), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving history"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// recordHistory appends entries to the history of a task in the name of
+// actor. Callers write it in the transaction that makes the change, so that
+// no change goes unrecorded.
+func recordHistory(historyRepo *repositories.HistoryRepository, actor middlewares.RequestContext, taskID uint, entries ...models.TaskHistory) error {
+	for i := range entries {
+		entries[i].TaskID = taskID
+		entries[i].ActorID = actor.UserID
+		entries[i].Actor = actor.Username
+	}
+	return historyRepo.Append(entries)
+}
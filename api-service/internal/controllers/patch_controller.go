@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+	maxPatchBytes  = 1 << 20
+)
+
+// patchError is a patch that cannot be applied, with the status to report it by.
+type patchError struct {
+	status  int
+	message string
+}
+
+func (e *patchError) Error() string {
+	return e.message
+}
+
+func invalidPatch(format string, args ...interface{}) error {
+	return &patchError{status: http.StatusUnprocessableEntity, message: fmt.Sprintf(format, args...)}
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// PatchTask changes a task with an RFC 7396 JSON Merge Patch: fields absent
+// from the patch are left alone and an explicit null clears a field. Sent as
+// application/json-patch+json the body is instead an RFC 6902 JSON Patch,
+// which is supported for the /tags array only.
+func (c *TaskController) PatchTask(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	contentType := ctx.ContentType()
+	if contentType != mergePatchType && contentType != jsonPatchType && contentType != "application/json" {
+		ctx.Header("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported patch format"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxPatchBytes))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patch data"})
+		return
+	}
+
+	// Verify task exists and belongs to user
+	existingTask, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+	if !checkIfMatch(ctx, existingTask) {
+		return
+	}
+	previous := *existingTask
+
+	var tags []models.Tag
+	var assignees []models.User
+	if contentType == jsonPatchType {
+		tags, err = applyJSONPatch(existingTask, userID, body)
+	} else {
+		tags, assignees, err = applyMergePatch(existingTask, body)
+	}
+	if err != nil {
+		var patchErr *patchError
+		if errors.As(err, &patchErr) {
+			ctx.JSON(patchErr.status, gin.H{"error": patchErr.message})
+		} else {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patch data"})
+		}
+		return
+	}
+
+	c.saveTask(ctx, userCtx, previous, existingTask, tags, assignees)
+}
+
+// applyMergePatch applies a merge patch to task. The returned tags and
+// assignees replace the task's own unless they are nil.
+func applyMergePatch(task *models.Task, body []byte) ([]models.Tag, []models.User, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, nil, err
+	}
+	if patch == nil {
+		return nil, nil, invalidPatch("A task patch must be a JSON object")
+	}
+
+	var tags []models.Tag
+	var assignees []models.User
+	for field, raw := range patch {
+		null := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		var err error
+		switch field {
+		case "title":
+			if null {
+				return nil, nil, invalidPatch("title cannot be cleared")
+			}
+			err = json.Unmarshal(raw, &task.Title)
+		case "description":
+			task.Description = ""
+			err = json.Unmarshal(raw, &task.Description)
+		case "status":
+			if null {
+				return nil, nil, invalidPatch("status cannot be cleared")
+			}
+			err = json.Unmarshal(raw, &task.Status)
+		case "priority":
+			if null {
+				return nil, nil, invalidPatch("priority cannot be cleared")
+			}
+			err = json.Unmarshal(raw, &task.Priority)
+		case "due_date":
+			var dueDate *time.Time
+			err = json.Unmarshal(raw, &dueDate)
+			task.DueDate = dueDate
+		case "parent_id":
+			var parentID *uint
+			err = json.Unmarshal(raw, &parentID)
+			task.ParentID = parentID
+		case "project_id":
+			var projectID *uint
+			err = json.Unmarshal(raw, &projectID)
+			task.ProjectID = projectID
+		case "recurrence":
+			task.Recurrence = ""
+			err = json.Unmarshal(raw, &task.Recurrence)
+		case "tags":
+			tags = []models.Tag{}
+			if !null {
+				tags, err = parseTagValues(raw)
+			}
+		case "assignees":
+			assignees = []models.User{}
+			if !null {
+				assignees, err = parseAssigneeValues(raw)
+			}
+		default:
+			return nil, nil, invalidPatch("%s cannot be patched", field)
+		}
+		if err != nil {
+			return nil, nil, &patchError{status: http.StatusBadRequest, message: "Invalid value for " + field}
+		}
+	}
+	return tags, assignees, nil
+}
+
+// applyJSONPatch applies the operations of a JSON Patch to the tags userID
+// put on task and returns the resulting tags; /tags leaves out the tags of
+// other members. Like the RFC requires, either every operation succeeds or
+// the patch has no effect.
+func applyJSONPatch(task *models.Task, userID uint, body []byte) ([]models.Tag, error) {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, tag := range task.Tags {
+		if tag.UserID == userID {
+			names = append(names, tag.Name)
+		}
+	}
+
+	for i, op := range ops {
+		if op.Path == "/tags" {
+			// The whole array: only replacing it, or asserting its value, makes sense
+			switch op.Op {
+			case "add", "replace":
+				tags, err := parseTagValues(op.Value)
+				if err != nil {
+					return nil, invalidPatch("Operation %d: value must be a list of tags", i)
+				}
+				names = names[:0]
+				for _, tag := range tags {
+					names = append(names, tag.Name)
+				}
+			case "remove":
+				names = nil
+			case "test":
+				tags, err := parseTagValues(op.Value)
+				if err != nil || !sameTagNames(names, tags) {
+					return nil, &patchError{status: http.StatusConflict, message: fmt.Sprintf("Operation %d: test failed", i)}
+				}
+			default:
+				return nil, invalidPatch("Operation %d: unsupported op %q", i, op.Op)
+			}
+			continue
+		}
+
+		pointer, ok := strings.CutPrefix(op.Path, "/tags/")
+		if !ok {
+			return nil, invalidPatch("Operation %d: JSON Patch is only supported for /tags", i)
+		}
+		index, err := strconv.Atoi(pointer)
+		if pointer == "-" && op.Op == "add" {
+			index, err = len(names), nil
+		}
+		if err != nil || index < 0 || index > len(names) || (index == len(names) && op.Op != "add") {
+			return nil, invalidPatch("Operation %d: no tag at %s", i, op.Path)
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			tag, err := parseTagValue(op.Value)
+			if err != nil {
+				return nil, invalidPatch("Operation %d: value must be a tag", i)
+			}
+			name := tag.Name
+			switch op.Op {
+			case "add":
+				names = append(names[:index], append([]string{name}, names[index:]...)...)
+			case "replace":
+				names[index] = name
+			case "test":
+				if names[index] != name {
+					return nil, &patchError{status: http.StatusConflict, message: fmt.Sprintf("Operation %d: test failed", i)}
+				}
+			}
+		case "remove":
+			names = append(names[:index], names[index+1:]...)
+		default:
+			return nil, invalidPatch("Operation %d: unsupported op %q", i, op.Op)
+		}
+	}
+
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	return uniqueTags(tags), nil
+}
+
+// parseTagValues reads a list of tags given either as names or as tag objects.
+func parseTagValues(raw json.RawMessage) ([]models.Tag, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	tags := make([]models.Tag, len(values))
+	for i, value := range values {
+		tag, err := parseTagValue(value)
+		if err != nil {
+			return nil, err
+		}
+		tags[i] = tag
+	}
+	return tags, nil
+}
+
+// parseTagValue reads a tag given by name or as a tag object, keeping the
+// owner of the latter so that other members' tags can be told apart.
+func parseTagValue(raw json.RawMessage) (models.Tag, error) {
+	var tag models.Tag
+	if err := json.Unmarshal(raw, &tag.Name); err != nil {
+		if err := json.Unmarshal(raw, &tag); err != nil {
+			return models.Tag{}, err
+		}
+	}
+
+	name := strings.TrimSpace(tag.Name)
+	if name == "" {
+		return models.Tag{}, errors.New("empty tag name")
+	}
+	return models.Tag{UserID: tag.UserID, Name: name}, nil
+}
+
+func sameTagNames(names []string, tags []models.Tag) bool {
+	if len(names) != len(tags) {
+		return false
+	}
+	for i, tag := range tags {
+		if names[i] != tag.Name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProjectRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type MemberRequest struct {
+	Username string             `json:"username"`
+	Role     models.ProjectRole `json:"role"`
+}
+
+type ProjectController struct {
+	projectRepo *repositories.ProjectRepository
+	historyRepo *repositories.HistoryRepository
+	userRepo    *repositories.UserRepository
+}
+
+func NewProjectController(projectRepo *repositories.ProjectRepository, historyRepo *repositories.HistoryRepository,
+	userRepo *repositories.UserRepository) *ProjectController {
+	return &ProjectController{projectRepo: projectRepo, historyRepo: historyRepo, userRepo: userRepo}
+}
+
+// GetProjects lists the active projects the user is a member of, or with ?archived=true the
+// archived ones.
+func (c *ProjectController) GetProjects(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	projects, err := c.projectRepo.FindByUserID(userID, ctx.Query("archived") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving projects"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, projects)
+}
+
+func (c *ProjectController) GetProjectByID(ctx *gin.Context) {
+	project, _, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, project)
+}
+
+func (c *ProjectController) CreateProject(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	var projectReq ProjectRequest
+	if err := ctx.ShouldBindJSON(&projectReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project data"})
+		return
+	}
+
+	project := models.Project{UserID: userID, Name: projectReq.Name}
+	if projectReq.Description != nil {
+		project.Description = *projectReq.Description
+	}
+	if err := project.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdProject, err := c.projectRepo.Create(project)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating project"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createdProject)
+}
+
+func (c *ProjectController) UpdateProject(ctx *gin.Context) {
+	var projectReq ProjectRequest
+	if err := ctx.ShouldBindJSON(&projectReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project data"})
+		return
+	}
+
+	project, _, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+
+	if projectReq.Name != "" {
+		project.Name = projectReq.Name
+	}
+	if projectReq.Description != nil {
+		project.Description = *projectReq.Description
+	}
+	if err := project.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedProject, err := c.projectRepo.Update(*project)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating project"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updatedProject)
+}
+
+// DeleteProject removes a project but keeps its tasks, which no longer belong
+// to any project afterwards. Archiving is the way to put a project and its
+// tasks out of sight.
+func (c *ProjectController) DeleteProject(ctx *gin.Context) {
+	project, actor, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+
+	projectID := strconv.FormatUint(uint64(project.ID), 10)
+	err := c.projectRepo.Transaction(func(tx *gorm.DB) error {
+		released, err := c.projectRepo.WithTx(tx).Delete(project.ID)
+		if err != nil {
+			return err
+		}
+		historyRepo := c.historyRepo.WithTx(tx)
+		for _, taskID := range released {
+			if err := recordHistory(historyRepo, actor, taskID, models.TaskHistory{Action: models.HistoryUpdated, Field: "project_id", OldValue: &projectID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting project"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// ArchiveProject puts a project and its tasks out of sight: task lists, bulk
+// filters and calendar feeds leave them out unless the project is named.
+func (c *ProjectController) ArchiveProject(ctx *gin.Context) {
+	c.setArchived(ctx, true)
+}
+
+func (c *ProjectController) UnarchiveProject(ctx *gin.Context) {
+	c.setArchived(ctx, false)
+}
+
+func (c *ProjectController) setArchived(ctx *gin.Context, archived bool) {
+	project, _, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+
+	if archived && project.ArchivedAt == nil {
+		now := time.Now()
+		project.ArchivedAt = &now
+	} else if !archived {
+		project.ArchivedAt = nil
+	}
+
+	updatedProject, err := c.projectRepo.Update(*project)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating project"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updatedProject)
+}
+
+func (c *ProjectController) GetMembers(ctx *gin.Context) {
+	project, _, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+
+	members, err := c.projectRepo.FindMembers(project.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving members"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+// AddMember invites a user to the project by username, as a viewer unless
+// another role is asked for.
+func (c *ProjectController) AddMember(ctx *gin.Context) {
+	var memberReq MemberRequest
+	if err := ctx.ShouldBindJSON(&memberReq); err != nil || memberReq.Username == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member data"})
+		return
+	}
+	if memberReq.Role == "" {
+		memberReq.Role = models.RoleViewer
+	}
+	if !memberReq.Role.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	project, _, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := c.userRepo.FindByUsername(memberReq.Username)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving user"})
+		}
+		return
+	}
+
+	if _, err := c.projectRepo.FindMember(project.ID, user.ID); err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving members"})
+		return
+	}
+
+	member, err := c.projectRepo.AddMember(models.ProjectMember{ProjectID: project.ID, UserID: user.ID, Role: memberReq.Role})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error adding member"})
+		return
+	}
+	member.Username = user.Username
+
+	ctx.JSON(http.StatusCreated, member)
+}
+
+func (c *ProjectController) UpdateMember(ctx *gin.Context) {
+	var memberReq MemberRequest
+	if err := ctx.ShouldBindJSON(&memberReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member data"})
+		return
+	}
+	if !memberReq.Role.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	project, _, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+	memberID, ok := memberParam(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.projectRepo.SetMemberRole(project.ID, memberID, memberReq.Role)
+	if !memberChanged(ctx, err) {
+		return
+	}
+
+	member, err := c.projectRepo.FindMember(project.ID, memberID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving member"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// RemoveMember takes a user out of the project. Owners may remove anyone,
+// other members only themselves.
+func (c *ProjectController) RemoveMember(ctx *gin.Context) {
+	project, actor, ok := c.project(ctx)
+	if !ok {
+		return
+	}
+	memberID, ok := memberParam(ctx)
+	if !ok {
+		return
+	}
+	if memberID != actor.UserID && !project.Role.Includes(models.RoleOwner) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	err := c.projectRepo.RemoveMember(project.ID, memberID)
+	if !memberChanged(ctx, err) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+func memberParam(ctx *gin.Context) (uint, bool) {
+	memberID, err := strconv.Atoi(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return uint(memberID), true
+}
+
+// memberChanged writes the error response for a failed membership change
+// and reports whether it succeeded.
+func memberChanged(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	if err == gorm.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+	} else if errors.Is(err, repositories.ErrLastOwner) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "A project needs at least one owner"})
+	} else {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating members"})
+	}
+	return false
+}
+
+// project loads the project named in the path, writing the error response
+// when it does not exist or the user is not a member of it. The routes
+// check the user's role before the handler runs.
+func (c *ProjectController) project(ctx *gin.Context) (*models.Project, middlewares.RequestContext, bool) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return nil, middlewares.RequestContext{}, false
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return nil, userCtx, false
+	}
+
+	project, err := c.projectRepo.FindByID(uint(projectID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving project"})
+		}
+		return nil, userCtx, false
+	}
+	return project, userCtx, true
+}
+
+// errLeaveProject explains why a task cannot be taken out of its project.
+const errLeaveProject = "Only the creator of a task can take it out of its project"
+
+// checkProject verifies that projectID is an active project the user may put
+// tasks in, writing the error response when it is not.
+func (c *TaskController) checkProject(ctx *gin.Context, projectID uint, userID uint) bool {
+	project, err := c.projectRepo.FindByID(projectID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Project not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving project"})
+		}
+		return false
+	}
+	if project.ArchivedAt != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Project is archived"})
+		return false
+	}
+	if !project.Role.Includes(models.RoleEditor) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return false
+	}
+	return true
+}
+
+// carrySubtasks moves the subtasks of a task that changed projects along
+// into its new project, fitting their statuses to its workflow, and returns
+// the IDs of those that moved.
+func (c *TaskController) carrySubtasks(actor middlewares.RequestContext, task *models.Task) ([]uint, error) {
+	descendants, err := c.taskRepo.FindDescendants([]uint{task.ID}, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []models.Task
+	var ids []uint
+	for _, descendant := range descendants {
+		if !sameID(descendant.ProjectID, task.ProjectID) {
+			moved = append(moved, descendant)
+			ids = append(ids, descendant.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	workflow, err := c.workflow(task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.taskRepo.SetProject(ids, task.ProjectID, workflow); err != nil {
+		return nil, err
+	}
+
+	for _, previous := range moved {
+		current := previous
+		current.ProjectID = task.ProjectID
+		current.Status = workflow.Fit(previous.Status, previous.Category).Name
+		if err := recordHistory(c.historyRepo, actor, previous.ID, models.DiffTask(previous, current)...); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/recurrence"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOccurrencePreview = 5
+	maxOccurrencePreview     = 100
+)
+
+func (c *TaskController) GetOccurrences(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	count := defaultOccurrencePreview
+	if value := ctx.Query("count"); value != "" {
+		count, err = strconv.Atoi(value)
+		if err != nil || count < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count"})
+			return
+		}
+		count = min(count, maxOccurrencePreview)
+	}
+
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	if task.Recurrence == "" || task.DueDate == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Task does not recur"})
+		return
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Stored recurrence rule is invalid"})
+		return
+	}
+
+	occurrences := []time.Time{}
+	current, seq := *task.DueDate, task.Occurrence
+	for len(occurrences) < count {
+		next, ok := rule.Next(current, seq)
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, next)
+		current, seq = next, seq+1
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recurrence": task.Recurrence, "occurrences": occurrences})
+}
+
+// checkRecurrence validates the recurrence rule of a task and stores it in
+// canonical form, writing the error response when it is unusable.
+func checkRecurrence(ctx *gin.Context, task *models.Task) bool {
+	if err := normalizeRecurrence(task); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func normalizeRecurrence(task *models.Task) error {
+	if task.Recurrence == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return err
+	}
+	if task.DueDate == nil {
+		return errors.New("Recurring tasks need a due date")
+	}
+
+	task.Recurrence = rule.String()
+	return nil
+}
+
+// spawnNextOccurrence creates the task that follows a completed occurrence of
+// a recurring task, carrying over its tags and offset reminders. The new task
+// starts in the initial status of its workflow. It returns nil once the series
+// has ended, or when the occurrence was completed before and reopened, as its
+// successor exists already.
+func (c *TaskController) spawnNextOccurrence(task *models.Task) (*models.Task, error) {
+	if task.Recurrence == "" || task.DueDate == nil || task.NextOccurrenceID != nil {
+		return nil, nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	due, ok := rule.Next(*task.DueDate, task.Occurrence)
+	if !ok {
+		return nil, nil
+	}
+
+	workflow, err := c.workflow(task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	initial := workflow.Initial()
+
+	next, err := c.taskRepo.Create(models.Task{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      initial.Name,
+		Category:    initial.Category,
+		DueDate:     &due,
+		Priority:    task.Priority,
+		UserID:      task.UserID,
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Recurrence:  task.Recurrence,
+		Occurrence:  task.Occurrence + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.taskRepo.SetNextOccurrence(task.ID, next.ID); err != nil {
+		return nil, err
+	}
+	task.NextOccurrenceID = &next.ID
+
+	tags, err := c.tagRepo.FindByTaskID(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if err := c.taskRepo.AddTag(next.ID, tag.ID); err != nil {
+			return nil, err
+		}
+	}
+	next.Tags = tags
+
+	if len(task.Assignees) > 0 {
+		if err := c.taskRepo.SetAssignees(next.ID, userIDs(task.Assignees)); err != nil {
+			return nil, err
+		}
+		next.Assignees = task.Assignees
+	}
+
+	if err := c.reminderRepo.CopyOffsets(task.ID, next); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreateReminderRequest struct {
+	RemindAt      *time.Time             `json:"remind_at"`
+	OffsetMinutes *int                   `json:"offset_minutes"`
+	Channel       models.ReminderChannel `json:"channel" binding:"required"`
+	Target        string                 `json:"target" binding:"required"`
+}
+
+func (c *TaskController) GetReminders(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// Verify task exists and belongs to user
+	if _, err := c.taskRepo.FindByID(uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	reminders, err := c.reminderRepo.FindByTaskID(uint(taskID), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving reminders"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reminders)
+}
+
+func (c *TaskController) CreateReminder(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var reminderReq CreateReminderRequest
+	if err := ctx.ShouldBindJSON(&reminderReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder data"})
+		return
+	}
+
+	if (reminderReq.RemindAt == nil) == (reminderReq.OffsetMinutes == nil) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of remind_at and offset_minutes is required"})
+		return
+	}
+	if reminderReq.OffsetMinutes != nil && *reminderReq.OffsetMinutes < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "offset_minutes cannot be negative"})
+		return
+	}
+	if !checkReminderTarget(ctx, reminderReq.Channel, reminderReq.Target) {
+		return
+	}
+
+	// Verify task exists and belongs to user
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	reminder := models.Reminder{
+		TaskID:        task.ID,
+		UserID:        userID,
+		RemindAt:      reminderReq.RemindAt,
+		OffsetMinutes: reminderReq.OffsetMinutes,
+		Channel:       reminderReq.Channel,
+		Target:        reminderReq.Target,
+	}
+	reminder.Schedule(task.DueDate)
+
+	createdReminder, err := c.reminderRepo.Create(reminder)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating reminder"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createdReminder)
+}
+
+func (c *TaskController) DeleteReminder(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	reminderID, err := strconv.Atoi(ctx.Param("reminderId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	// Verify task exists and belongs to user
+	if _, err := c.taskRepo.FindByID(uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	if err := c.reminderRepo.Delete(uint(reminderID), uint(taskID), userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting reminder"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
+}
+
+// checkReminderTarget verifies that target is an address the channel can
+// deliver to, writing the error response when it is not.
+func checkReminderTarget(ctx *gin.Context, channel models.ReminderChannel, target string) bool {
+	switch channel {
+	case models.ChannelEmail:
+		if _, err := mail.ParseAddress(target); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+			return false
+		}
+	case models.ChannelWebhook:
+		if !isHTTPURL(target) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook URL"})
+			return false
+		}
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder channel"})
+		return false
+	}
+	return true
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
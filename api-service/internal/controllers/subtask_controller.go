@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MoveTaskRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+func (c *TaskController) GetSubtasks(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+
+	descendants, err := c.taskRepo.FindDescendants([]uint{task.ID}, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving subtasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, subtree(*task, childrenByParent(descendants)))
+}
+
+// MoveTask puts a task under another parent, or at the top level. Under a
+// parent in another project, the task and its subtasks go into that project
+// with statuses fitted to its workflow, as when the project is changed.
+func (c *TaskController) MoveTask(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var moveReq MoveTaskRequest
+	if err := ctx.ShouldBindJSON(&moveReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid move data"})
+		return
+	}
+
+	// Verify task exists and belongs to user
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+	if !checkIfMatch(ctx, task) {
+		return
+	}
+
+	previous := *task
+	movingProject := false
+	if moveReq.ParentID != nil {
+		parent, ok := c.checkParent(ctx, task.ID, *moveReq.ParentID, userID)
+		if !ok {
+			return
+		}
+		movingProject = !sameID(task.ProjectID, parent.ProjectID)
+		if movingProject && parent.ProjectID != nil && !c.checkProject(ctx, *parent.ProjectID, userID) {
+			return
+		}
+		// Outside a project a task is private to its creator
+		if movingProject && parent.ProjectID == nil && task.UserID != userID {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": errLeaveProject})
+			return
+		}
+		task.ProjectID = parent.ProjectID
+	}
+	task.ParentID = moveReq.ParentID
+
+	var moved *models.Task
+	err = c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		txc := c.withTx(tx)
+		if movingProject {
+			fitted := *task
+			if err := txc.applyWorkflow(&fitted, &previous); err != nil {
+				return err
+			}
+			var err error
+			if moved, err = txc.taskRepo.Update(fitted); err != nil {
+				return err
+			}
+			// Subtasks go along to the task's new project
+			if _, err := txc.carrySubtasks(userCtx, moved); err != nil {
+				return err
+			}
+		} else {
+			if err := txc.taskRepo.Move(task.ID, previous.Version, moveReq.ParentID); err != nil {
+				return err
+			}
+			updated := *task
+			updated.Version++
+			moved = &updated
+		}
+		return recordHistory(txc.historyRepo, userCtx, moved.ID, models.DiffTask(previous, *moved)...)
+	})
+	if err != nil {
+		writeTaskError(ctx, err, "Error moving task")
+		return
+	}
+	task = moved
+
+	descendants, err := c.taskRepo.FindDescendants([]uint{task.ID}, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving subtasks"})
+		return
+	}
+
+	c.dispatcher.Emit(userID, models.EventTaskUpdated, task)
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, subtree(*task, childrenByParent(descendants)))
+}
+
+// errParentProject explains why a task cannot be placed under a parent.
+const errParentProject = "A subtask must be in the same project as its parent"
+
+// checkParent verifies that parentID is a task the user may edit and that
+// taskID may be placed under, and returns it, writing the error response
+// when it is not. A taskID of zero stands for a task that does not exist
+// yet. Callers check that the parent is in the task's project.
+func (c *TaskController) checkParent(ctx *gin.Context, taskID uint, parentID uint, userID uint) (*models.Task, bool) {
+	parent, err := c.taskRepo.FindByID(parentID, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Parent task not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving parent task"})
+		}
+		return nil, false
+	}
+	role, err := c.authorizer.RoleOf(parent, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving parent task"})
+		return nil, false
+	}
+	if !role.Includes(models.RoleEditor) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return nil, false
+	}
+
+	if taskID == 0 {
+		return parent, true
+	}
+
+	cycle := parentID == taskID
+	if !cycle {
+		var err error
+		if cycle, err = c.taskRepo.IsDescendant(parentID, taskID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving parent task"})
+			return nil, false
+		}
+	}
+	if cycle {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A task cannot be moved below itself"})
+		return nil, false
+	}
+
+	return parent, true
+}
+
+// withProgress fills in the completion percentage of every task in the list
+// that has subtasks, counting those the user can see.
+func (c *TaskController) withProgress(userTasks []models.UserTask, userID uint) error {
+	ids := make([]uint, len(userTasks))
+	for i, userTask := range userTasks {
+		ids[i] = userTask.Task.ID
+	}
+
+	descendants, err := c.taskRepo.FindDescendants(ids, userID)
+	if err != nil {
+		return err
+	}
+
+	children := childrenByParent(descendants)
+	for i := range userTasks {
+		userTasks[i].Progress = progress(userTasks[i].Task, children)
+	}
+	return nil
+}
+
+func childrenByParent(tasks []models.Task) map[uint][]models.Task {
+	children := make(map[uint][]models.Task)
+	for _, task := range tasks {
+		if task.ParentID != nil {
+			children[*task.ParentID] = append(children[*task.ParentID], task)
+		}
+	}
+	return children
+}
+
+func subtree(task models.Task, children map[uint][]models.Task) models.UserTask {
+	node := models.UserTask{Task: task, Tags: task.Tags, Assignees: task.Assignees, Progress: progress(task, children)}
+	for _, child := range children[task.ID] {
+		node.Subtasks = append(node.Subtasks, subtree(child, children))
+	}
+	return node
+}
+
+func progress(task models.Task, children map[uint][]models.Task) *int {
+	if len(children[task.ID]) == 0 {
+		return nil
+	}
+	percent := int(math.Round(completion(task, children) * 100))
+	return &percent
+}
+
+// completion rolls progress up from the leaves: a task without subtasks is
+// either done or not, a parent is the mean of its direct subtasks.
+func completion(task models.Task, children map[uint][]models.Task) float64 {
+	subtasks := children[task.ID]
+	if len(subtasks) == 0 {
+		if task.Category == models.CategoryDone {
+			return 1
+		}
+		return 0
+	}
+
+	total := 0.0
+	for _, subtask := range subtasks {
+		total += completion(subtask, children)
+	}
+	return total / float64(len(subtasks))
+}
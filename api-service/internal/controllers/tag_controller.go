@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TagRequest struct {
+	Name        string  `json:"name"`
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+type MergeTagRequest struct {
+	Into uint `json:"into" binding:"required"`
+}
+
+type TagController struct {
+	tagRepo     *repositories.TagRepository
+	historyRepo *repositories.HistoryRepository
+	dispatcher  *webhooks.Dispatcher
+}
+
+func NewTagController(tagRepo *repositories.TagRepository, historyRepo *repositories.HistoryRepository, dispatcher *webhooks.Dispatcher) *TagController {
+	return &TagController{tagRepo: tagRepo, historyRepo: historyRepo, dispatcher: dispatcher}
+}
+
+// GetTags returns the user's tags as a plain list, or arranged by path with
+// ?tree=true.
+func (c *TagController) GetTags(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	tags, err := c.tagRepo.FindByUserID(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tags"})
+		return
+	}
+	if ctx.Query("tree") != "true" {
+		ctx.JSON(http.StatusOK, tags)
+		return
+	}
+
+	taskIDs, err := c.tagRepo.FindTaskIDs(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tagTree(tags, taskIDs))
+}
+
+func (c *TagController) GetTagByID(ctx *gin.Context) {
+	tag, _, ok := c.tag(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tag)
+}
+
+func (c *TagController) CreateTag(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	var tagReq TagRequest
+	if err := ctx.ShouldBindJSON(&tagReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag data"})
+		return
+	}
+
+	tag := models.Tag{UserID: userID, Name: tagReq.Name}
+	if tagReq.Color != nil {
+		tag.Color = *tagReq.Color
+	}
+	if tagReq.Description != nil {
+		tag.Description = *tagReq.Description
+	}
+	if err := tag.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !c.checkNameFree(ctx, tag) {
+		return
+	}
+
+	createdTag, err := c.tagRepo.Create(tag)
+	if err != nil {
+		// A tag of the same name may have been created since checkNameFree
+		if !repositories.IsDuplicate(err) || c.checkNameFree(ctx, tag) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating tag"})
+		}
+		return
+	}
+
+	c.dispatcher.Emit(userID, models.EventTagCreated, createdTag)
+	ctx.JSON(http.StatusCreated, createdTag)
+}
+
+// UpdateTag changes a tag. A new name shows on every task carrying the tag,
+// and is recorded in the history of each.
+func (c *TagController) UpdateTag(ctx *gin.Context) {
+	var tagReq TagRequest
+	if err := ctx.ShouldBindJSON(&tagReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag data"})
+		return
+	}
+
+	tag, actor, ok := c.tag(ctx)
+	if !ok {
+		return
+	}
+	previous := *tag
+
+	if tagReq.Name != "" {
+		tag.Name = tagReq.Name
+	}
+	if tagReq.Color != nil {
+		tag.Color = *tagReq.Color
+	}
+	if tagReq.Description != nil {
+		tag.Description = *tagReq.Description
+	}
+	if err := tag.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	renamed := tag.Name != previous.Name
+	if renamed && !c.checkNameFree(ctx, *tag) {
+		return
+	}
+
+	err := c.tagRepo.Transaction(func(tx *gorm.DB) error {
+		taskIDs, err := c.tagRepo.WithTx(tx).Update(*tag)
+		if err != nil || !renamed {
+			return err
+		}
+		historyRepo := c.historyRepo.WithTx(tx)
+		for _, taskID := range taskIDs {
+			if err := recordHistory(historyRepo, actor, taskID, models.TaskHistory{Action: models.HistoryUpdated, Field: "tags",
+				OldValue: &previous.Name, NewValue: &tag.Name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// A tag of the new name may have been created since checkNameFree
+		if !repositories.IsDuplicate(err) || c.checkNameFree(ctx, *tag) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating tag"})
+		}
+		return
+	}
+
+	c.dispatcher.Emit(actor.UserID, models.EventTagUpdated, tag)
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag takes a tag off every task carrying it and deletes it.
+func (c *TagController) DeleteTag(ctx *gin.Context) {
+	tag, actor, ok := c.tag(ctx)
+	if !ok {
+		return
+	}
+
+	var taskIDs []uint
+	err := c.tagRepo.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if taskIDs, err = c.tagRepo.WithTx(tx).Delete(tag.ID); err != nil {
+			return err
+		}
+		historyRepo := c.historyRepo.WithTx(tx)
+		for _, taskID := range taskIDs {
+			if err := recordHistory(historyRepo, actor, taskID, models.TaskHistory{Action: models.HistoryTagRemoved, Field: "tags", OldValue: &tag.Name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting tag"})
+		return
+	}
+
+	c.dispatcher.Emit(actor.UserID, models.EventTagDeleted, gin.H{"tag": tag})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully", "tasks": len(taskIDs)})
+}
+
+// MergeTag folds the tag into another of the user's tags, which takes its
+// place on every task, and deletes it.
+func (c *TagController) MergeTag(ctx *gin.Context) {
+	var mergeReq MergeTagRequest
+	if err := ctx.ShouldBindJSON(&mergeReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merge data"})
+		return
+	}
+
+	source, actor, ok := c.tag(ctx)
+	if !ok {
+		return
+	}
+	if mergeReq.Into == source.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A tag cannot be merged into itself"})
+		return
+	}
+	target, err := c.tagRepo.FindByID(mergeReq.Into, actor.UserID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Target tag not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tag"})
+		}
+		return
+	}
+
+	var taskIDs []uint
+	err = c.tagRepo.Transaction(func(tx *gorm.DB) error {
+		var added []uint
+		var err error
+		if taskIDs, added, err = c.tagRepo.WithTx(tx).Merge(source.ID, target.ID); err != nil {
+			return err
+		}
+
+		gained := make(map[uint]bool, len(added))
+		for _, taskID := range added {
+			gained[taskID] = true
+		}
+		historyRepo := c.historyRepo.WithTx(tx)
+		for _, taskID := range taskIDs {
+			history := []models.TaskHistory{{Action: models.HistoryTagRemoved, Field: "tags", OldValue: &source.Name}}
+			if gained[taskID] {
+				history = append(history, models.TaskHistory{Action: models.HistoryTagAdded, Field: "tags", NewValue: &target.Name})
+			}
+			if err := recordHistory(historyRepo, actor, taskID, history...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		} else if repositories.IsDuplicate(err) {
+			// A task took the target tag while the merge was moving it over
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Tags changed during the merge"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error merging tags"})
+		}
+		return
+	}
+
+	c.dispatcher.Emit(actor.UserID, models.EventTagDeleted, gin.H{"tag": source, "merged_into": target.ID})
+	ctx.JSON(http.StatusOK, gin.H{"tag": target, "tasks": len(taskIDs)})
+}
+
+// checkNameFree verifies that the user has no other tag named like tag,
+// writing the error response when they do.
+func (c *TagController) checkNameFree(ctx *gin.Context, tag models.Tag) bool {
+	existing, err := c.tagRepo.FindByName(tag.UserID, tag.Name)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tag"})
+		return false
+	}
+	if err == nil && existing.ID != tag.ID {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "A tag with that name already exists", "id": existing.ID})
+		return false
+	}
+	return true
+}
+
+// tag loads the user's tag named in the path, writing the error response
+// when there is none.
+func (c *TagController) tag(ctx *gin.Context) (*models.Tag, middlewares.RequestContext, bool) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return nil, middlewares.RequestContext{}, false
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	tagID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return nil, userCtx, false
+	}
+
+	tag, err := c.tagRepo.FindByID(uint(tagID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tag"})
+		}
+		return nil, userCtx, false
+	}
+	return tag, userCtx, true
+}
+
+// tagTree arranges tags by path. A task counts once on the level of each tag
+// it carries and on every level above, however many of its tags share one.
+func tagTree(tags []models.Tag, taskIDs map[uint][]uint) []models.TagNode {
+	type level struct {
+		node     models.TagNode
+		tasks    map[uint]bool
+		children []string
+	}
+	levels := make(map[string]*level)
+	var roots []string
+
+	for i := range tags {
+		parts := strings.Split(tags[i].Name, models.TagSeparator)
+		parent := ""
+		for depth := range parts {
+			path := strings.Join(parts[:depth+1], models.TagSeparator)
+			current, ok := levels[path]
+			if !ok {
+				current = &level{node: models.TagNode{Name: parts[depth], Path: path}, tasks: make(map[uint]bool)}
+				levels[path] = current
+				if parent == "" {
+					roots = append(roots, path)
+				} else {
+					levels[parent].children = append(levels[parent].children, path)
+				}
+			}
+			for _, taskID := range taskIDs[tags[i].ID] {
+				current.tasks[taskID] = true
+			}
+			parent = path
+		}
+		levels[tags[i].Name].node.Tag = &tags[i]
+	}
+
+	var build func(paths []string) []models.TagNode
+	build = func(paths []string) []models.TagNode {
+		sort.Strings(paths)
+		nodes := make([]models.TagNode, len(paths))
+		for i, path := range paths {
+			current := levels[path]
+			nodes[i] = current.node
+			nodes[i].TaskCount = len(current.tasks)
+			nodes[i].Children = build(current.children)
+		}
+		return nodes
+	}
+	return build(roots)
+}
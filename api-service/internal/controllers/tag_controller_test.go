@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"taskmango/apisvc/internal/models"
+)
+
+// describeTree lists the nodes of a tag tree depth first, one line each.
+func describeTree(nodes []models.TagNode, depth int) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		tag := "-"
+		if node.Tag != nil {
+			tag = fmt.Sprint(node.Tag.ID)
+		}
+		fmt.Fprintf(&b, "%s%s %s tag=%s tasks=%d\n", strings.Repeat("  ", depth), node.Name, node.Path, tag, node.TaskCount)
+		b.WriteString(describeTree(node.Children, depth+1))
+	}
+	return b.String()
+}
+
+func TestTagTree(t *testing.T) {
+	tags := []models.Tag{
+		{ID: 1, Name: "work/billing"},
+		{ID: 2, Name: "work"},
+		{ID: 3, Name: "work/billing/q1"},
+		{ID: 4, Name: "home"},
+		{ID: 5, Name: "errands/shop"},
+	}
+	// Task 11 carries both work and work/billing but counts once on each level
+	taskIDs := map[uint][]uint{1: {10, 11}, 2: {11}, 3: {12}, 5: {13}}
+
+	got := describeTree(tagTree(tags, taskIDs), 0)
+	want := `errands errands tag=- tasks=1
+  shop errands/shop tag=5 tasks=1
+home home tag=4 tasks=0
+work work tag=2 tasks=3
+  billing work/billing tag=1 tasks=3
+    q1 work/billing/q1 tag=3 tasks=1
+`
+	if got != want {
+		t.Errorf("tagTree =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTagTreeEmpty(t *testing.T) {
+	if tree := tagTree(nil, nil); len(tree) != 0 {
+		t.Errorf("tagTree(nil) = %v, want no nodes", tree)
+	}
+}
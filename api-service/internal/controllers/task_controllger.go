@@ -1,24 +1,47 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"taskmango/apisvc/internal/authz"
 	"taskmango/apisvc/internal/middlewares"
 	"taskmango/apisvc/internal/models"
 	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/search"
+	"taskmango/apisvc/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
 type TaskController struct {
-	taskRepo *repositories.TaskRepository
-	tagRepo  *repositories.TagRepository
+	taskRepo       *repositories.TaskRepository
+	tagRepo        *repositories.TagRepository
+	dependencyRepo *repositories.DependencyRepository
+	reminderRepo   *repositories.ReminderRepository
+	historyRepo    *repositories.HistoryRepository
+	projectRepo    *repositories.ProjectRepository
+	workflowRepo   *repositories.WorkflowRepository
+	userRepo       *repositories.UserRepository
+	authorizer     *authz.Authorizer
+	dispatcher     *webhooks.Dispatcher
 }
 
-func NewTaskController(taskRepo *repositories.TaskRepository, tagRepo *repositories.TagRepository) *TaskController {
-	return &TaskController{taskRepo: taskRepo, tagRepo: tagRepo}
+func NewTaskController(taskRepo *repositories.TaskRepository, tagRepo *repositories.TagRepository, dependencyRepo *repositories.DependencyRepository,
+	reminderRepo *repositories.ReminderRepository, historyRepo *repositories.HistoryRepository, projectRepo *repositories.ProjectRepository,
+	workflowRepo *repositories.WorkflowRepository, userRepo *repositories.UserRepository, authorizer *authz.Authorizer,
+	dispatcher *webhooks.Dispatcher) *TaskController {
+	return &TaskController{taskRepo: taskRepo, tagRepo: tagRepo, dependencyRepo: dependencyRepo, reminderRepo: reminderRepo, historyRepo: historyRepo,
+		projectRepo: projectRepo, workflowRepo: workflowRepo, userRepo: userRepo, authorizer: authorizer, dispatcher: dispatcher}
 }
 
 func (c *TaskController) GetTasks(ctx *gin.Context) {
@@ -33,18 +56,72 @@ func (c *TaskController) GetTasks(ctx *gin.Context) {
 
 	filter := models.TaskFilter{
 		Status:        models.TaskStatus(ctx.Query("status")),
+		Category:      models.StatusCategory(ctx.Query("category")),
 		Priority:      models.TaskPriority(ctx.Query("priority")),
 		DueDateBefore: ctx.Query("due_date_before"),
 		DueDateAfter:  ctx.Query("due_date_after"),
 		TagName:       ctx.Query("tagName"),
+		Query:         strings.TrimSpace(ctx.Query("q")),
+		ParentID:      ctx.Query("parent_id"),
+		ProjectID:     ctx.Query("project_id"),
+		Assignee:      ctx.Query("assignee"),
+	}
+	if filter.ParentID != "" && filter.ParentID != "root" {
+		if _, err := strconv.Atoi(filter.ParentID); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent ID"})
+			return
+		}
+	}
+	if filter.ProjectID != "" && filter.ProjectID != "none" {
+		if _, err := strconv.Atoi(filter.ProjectID); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+	}
+	if filter.Category != "" && !filter.Category.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+		return
+	}
+	// Only "me" and "none" are supported, other users' assignments are theirs to list
+	if filter.Assignee != "" && filter.Assignee != "me" && filter.Assignee != "none" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee"})
+		return
+	}
+
+	page := models.Pagination{
+		Limit:  defaultPageLimit,
+		Cursor: ctx.Query("cursor"),
+		Sort:   ctx.Query("sort"),
+	}
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		page.Limit = min(value, maxPageLimit)
+	}
+
+	tasks, nextCursor, err := c.taskRepo.FindByUserID(userID, filter, page)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		} else if errors.Is(err, repositories.ErrInvalidSort) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tasks"})
+		}
+		return
 	}
 
-	tasks, err := c.taskRepo.FindByUserID(userID, filter)
+	total, err := c.taskRepo.CountByUserID(userID, filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tasks"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error counting tasks"})
 		return
 	}
 
+	terms := search.Terms(filter.Query)
+
 	userTasks := make([]models.UserTask, len(tasks))
 	for i, task := range tasks {
 		tags, err := c.tagRepo.FindByTaskID(task.ID)
@@ -52,10 +129,16 @@ func (c *TaskController) GetTasks(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tags"})
 			return
 		}
-		userTasks[i] = models.UserTask{Task: task, Tags: tags}
+		userTasks[i] = models.UserTask{Task: task, Tags: tags, Assignees: task.Assignees, Highlights: highlights(task, terms)}
+	}
+
+	if err := c.enrich(userTasks, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task details"})
+		return
 	}
 
-	ctx.JSON(http.StatusOK, userTasks)
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, models.TaskPage{Tasks: userTasks, NextCursor: nextCursor})
 }
 
 func (c *TaskController) GetTaskByID(ctx *gin.Context) {
@@ -84,13 +167,27 @@ func (c *TaskController) GetTaskByID(ctx *gin.Context) {
 		return
 	}
 
+	// Subtask progress and dependencies are not covered by the version
+	etag := taskETag(task)
+	ctx.Header("ETag", etag)
+	if header := ctx.GetHeader("If-None-Match"); header != "" && etagMatches(header, etag, true) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
 	tags, err := c.tagRepo.FindByTaskID(uint(taskID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tags"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, models.UserTask{Task: *task, Tags: tags})
+	userTasks := []models.UserTask{{Task: *task, Tags: tags, Assignees: task.Assignees}}
+	if err := c.enrich(userTasks, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task details"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userTasks[0])
 }
 
 func (c *TaskController) CreateTask(ctx *gin.Context) {
@@ -109,36 +206,85 @@ func (c *TaskController) CreateTask(ctx *gin.Context) {
 		return
 	}
 
-	// Set default values if not provided
-	if taskReq.Status == "" {
-		taskReq.Status = models.StatusTodo
-	}
+	// Set default values if not provided; the status depends on the project
 	if taskReq.Priority == "" {
 		taskReq.Priority = models.PriorityMedium
 	}
 
 	taskReq.UserID = userID
+	taskReq.Occurrence = 1
+	taskReq.Version = 1
 
-	createdTask, err := c.taskRepo.Create(taskReq)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating task"})
+	if taskReq.ParentID != nil {
+		parent, ok := c.checkParent(ctx, 0, *taskReq.ParentID, userID)
+		if !ok {
+			return
+		}
+		// A new subtask goes in its parent's project unless told otherwise
+		if taskReq.ProjectID == nil {
+			taskReq.ProjectID = parent.ProjectID
+		}
+		if !sameID(taskReq.ProjectID, parent.ProjectID) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": errParentProject})
+			return
+		}
+	}
+	if taskReq.ProjectID != nil && !c.checkProject(ctx, *taskReq.ProjectID, userID) {
+		return
+	}
+	if !checkRecurrence(ctx, &taskReq) {
+		return
+	}
+	assignees, ok := c.checkAssignees(ctx, &taskReq, taskReq.Assignees)
+	if !ok {
 		return
 	}
 
-	// Handle tags if provided
-	if len(taskReq.Tags) > 0 {
-		for _, tagName := range taskReq.Tags {
-			tag, err := c.tagRepo.FindOrCreateByName(tagName.Name)
-			if err != nil {
-				continue // Skip if tag creation fails
+	// The task is only created together with all of its tags and assignees,
+	// and in a status of the workflow as it stands when it is written
+	var createdTask *models.Task
+	var tags []models.Tag
+	var events []pendingEvent
+	err := c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		txc := c.withTx(tx)
+		task := taskReq
+		if err := txc.applyWorkflow(&task, nil); err != nil {
+			return err
+		}
+		if err := task.Validate(); err != nil {
+			return &taskError{http.StatusBadRequest, err.Error()}
+		}
+
+		var err error
+		createdTask, err = txc.taskRepo.Create(task)
+		if err != nil {
+			return err
+		}
+		if len(assignees) > 0 {
+			if err := txc.taskRepo.SetAssignees(createdTask.ID, userIDs(assignees)); err != nil {
+				return err
 			}
-			_ = c.taskRepo.AddTag(createdTask.ID, tag.ID)
 		}
+		createdTask.Assignees = assignees
+
+		tags, events, err = txc.setTags(createdTask.ID, userID, taskReq.Tags)
+		if err != nil {
+			return err
+		}
+		createdTask.Tags = tags
+		return recordHistory(txc.historyRepo, userCtx, createdTask.ID, models.TaskHistory{Action: models.HistoryCreated})
+	})
+	if err != nil {
+		writeTaskError(ctx, err, "Error creating task")
+		return
 	}
 
-	// Return the created task with tags
-	tags, _ := c.tagRepo.FindByTaskID(createdTask.ID)
-	ctx.JSON(http.StatusCreated, models.UserTask{Task: *createdTask, Tags: tags})
+	for _, event := range events {
+		c.dispatcher.Emit(userID, event.event, event.data)
+	}
+	c.dispatcher.Emit(userID, models.EventTaskCreated, createdTask)
+	ctx.Header("ETag", taskETag(createdTask))
+	ctx.JSON(http.StatusCreated, models.UserTask{Task: *createdTask, Tags: tags, Assignees: createdTask.Assignees})
 }
 
 func (c *TaskController) UpdateTask(ctx *gin.Context) {
@@ -173,6 +319,10 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 		}
 		return
 	}
+	if !checkIfMatch(ctx, existingTask) {
+		return
+	}
+	previous := *existingTask
 
 	// Update task fields
 	if taskReq.Title != "" {
@@ -190,34 +340,245 @@ func (c *TaskController) UpdateTask(ctx *gin.Context) {
 	if taskReq.DueDate != nil {
 		existingTask.DueDate = taskReq.DueDate
 	}
+	if taskReq.ParentID != nil {
+		existingTask.ParentID = taskReq.ParentID
+	}
+	if taskReq.ProjectID != nil {
+		existingTask.ProjectID = taskReq.ProjectID
+	}
+	if taskReq.Recurrence != "" {
+		existingTask.Recurrence = taskReq.Recurrence
+	}
 
-	updatedTask, err := c.taskRepo.Update(*existingTask)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating task"})
+	c.saveTask(ctx, userCtx, previous, existingTask, taskReq.Tags, taskReq.Assignees)
+}
+
+// saveTask checks and stores the changes UpdateTask or PatchTask made to a
+// task, then writes the updated task as the response. The task's tags and
+// assignees are replaced by tags and assignees unless they are nil.
+func (c *TaskController) saveTask(ctx *gin.Context, actor middlewares.RequestContext, previous models.Task, task *models.Task, tags []models.Tag,
+	assignees []models.User) {
+	userID := actor.UserID
+
+	movingProject := !sameID(previous.ProjectID, task.ProjectID)
+	if task.ParentID != nil && (movingProject || !sameID(previous.ParentID, task.ParentID)) {
+		parent, ok := c.checkParent(ctx, task.ID, *task.ParentID, userID)
+		if !ok {
+			return
+		}
+		if !sameID(task.ProjectID, parent.ProjectID) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": errParentProject})
+			return
+		}
+	}
+	if movingProject && task.ProjectID != nil && !c.checkProject(ctx, *task.ProjectID, userID) {
 		return
 	}
-
-	// Handle tags update if provided
-	if taskReq.Tags != nil {
-		// First remove all existing tags
-		if err := c.taskRepo.RemoveAllTags(updatedTask.ID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating tags"})
+	// Outside a project a task is private to its creator
+	if movingProject && task.ProjectID == nil && task.UserID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": errLeaveProject})
+		return
+	}
+	if err := task.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkRecurrence(ctx, task) {
+		return
+	}
+	if assignees != nil {
+		var ok bool
+		if assignees, ok = c.checkAssignees(ctx, task, assignees); !ok {
 			return
 		}
+	}
+
+	// The task, its subtasks, tags, assignees, reminders and next occurrence
+	// are all written or none is, in a status the workflow allows as it
+	// stands when they are
+	force := ctx.Query("force") == "true"
+	var updatedTask, nextOccurrence *models.Task
+	var currentTags []models.Tag
+	var events []pendingEvent
+	err := c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		txc := c.withTx(tx)
+		events, nextOccurrence = nil, nil
+		fitted := *task
+		if err := txc.applyWorkflow(&fitted, &previous); err != nil {
+			return err
+		}
+
+		// Refuse to complete a task while it waits on others, unless forced
+		completing := fitted.Category == models.CategoryDone && previous.Category != models.CategoryDone
+		if completing && !force {
+			blockers, err := txc.dependencyRepo.FindUnfinishedBlockers(fitted.ID)
+			if err != nil {
+				return err
+			}
+			if len(blockers) > 0 {
+				return &blockedError{blockers}
+			}
+		}
+
+		var err error
+		updatedTask, err = txc.taskRepo.Update(fitted)
+		if err != nil {
+			return err
+		}
+
+		// Subtasks go along to the task's new project
+		if movingProject {
+			if _, err := txc.carrySubtasks(actor, updatedTask); err != nil {
+				return err
+			}
+		}
+
+		if assignees != nil {
+			if err := txc.taskRepo.SetAssignees(updatedTask.ID, userIDs(assignees)); err != nil {
+				return err
+			}
+			updatedTask.Assignees = assignees
+		}
+
+		// Offset reminders follow the due date
+		if !sameTime(previous.DueDate, updatedTask.DueDate) {
+			if err := txc.reminderRepo.Reschedule(updatedTask.ID, updatedTask.DueDate); err != nil {
+				return err
+			}
+		}
+
+		if tags != nil {
+			currentTags, events, err = txc.setTags(updatedTask.ID, userID, tags)
+		} else {
+			currentTags, err = txc.tagRepo.FindByTaskID(updatedTask.ID)
+		}
+		if err != nil {
+			return err
+		}
+		updatedTask.Tags = currentTags
 
-		// Add new tags
-		for _, tagName := range taskReq.Tags {
-			tag, err := c.tagRepo.FindOrCreateByName(tagName.Name)
+		// Completing an occurrence of a recurring task schedules the next one
+		if completing {
+			nextOccurrence, err = txc.spawnNextOccurrence(updatedTask)
 			if err != nil {
-				continue // Skip if tag creation fails
+				return err
+			}
+			if nextOccurrence != nil {
+				if err := recordHistory(txc.historyRepo, actor, nextOccurrence.ID, models.TaskHistory{Action: models.HistoryCreated}); err != nil {
+					return err
+				}
 			}
-			_ = c.taskRepo.AddTag(updatedTask.ID, tag.ID)
+		}
+
+		changes := models.DiffTask(previous, *updatedTask)
+		if tags != nil {
+			changes = append(changes, models.DiffTags(previous.Tags, currentTags)...)
+		}
+		if assignees != nil {
+			changes = append(changes, models.DiffAssignees(previous.Assignees, assignees)...)
+		}
+		return recordHistory(txc.historyRepo, actor, updatedTask.ID, changes...)
+	})
+	if err != nil {
+		writeTaskError(ctx, err, "Error updating task")
+		return
+	}
+
+	for _, event := range events {
+		c.dispatcher.Emit(userID, event.event, event.data)
+	}
+	if nextOccurrence != nil {
+		c.dispatcher.Emit(userID, models.EventTaskCreated, nextOccurrence)
+	}
+	c.dispatcher.Emit(userID, models.EventTaskUpdated, updatedTask)
+	if tags != nil {
+		c.emitTagChanges(userID, updatedTask.ID, previous.Tags, currentTags)
+	}
+
+	userTasks := []models.UserTask{{Task: *updatedTask, Tags: currentTags, Assignees: updatedTask.Assignees, NextOccurrence: nextOccurrence}}
+	if err := c.enrich(userTasks, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task details"})
+		return
+	}
+	ctx.Header("ETag", taskETag(updatedTask))
+	ctx.JSON(http.StatusOK, userTasks[0])
+}
+
+// taskError is a task that cannot be written as asked, with the status code
+// to report it by.
+type taskError struct {
+	code    int
+	message string
+}
+
+func (e *taskError) Error() string {
+	return e.message
+}
+
+// blockedError is a task that cannot be completed while the tasks blocking
+// it are unfinished.
+type blockedError struct {
+	blockers []uint
+}
+
+func (e *blockedError) Error() string {
+	return "task is blocked by unfinished tasks"
+}
+
+// writeTaskError writes the response for a task write that failed: the
+// reason it was refused for, a version conflict, a clash with a row written
+// concurrently or else failure.
+func writeTaskError(ctx *gin.Context, err error, failure string) {
+	var taskErr *taskError
+	var blocked *blockedError
+	if errors.As(err, &taskErr) {
+		ctx.JSON(taskErr.code, gin.H{"error": taskErr.message})
+	} else if errors.As(err, &blocked) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Task is blocked by unfinished tasks", "blocked_by": blocked.blockers})
+	} else if errors.Is(err, repositories.ErrVersionConflict) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": "Task has been modified"})
+	} else if repositories.IsDuplicate(err) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Task conflicts with a concurrent change"})
+	} else {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": failure})
+	}
+}
+
+// setTags replaces the user's tags on a task by the named ones, creating
+// those the user does not have yet, and returns all of the task's tags along
+// with the events for the created ones. Tags of other members stay put, and
+// those sent back as read, with their owner, are skipped.
+func (c *TaskController) setTags(taskID uint, userID uint, tags []models.Tag) ([]models.Tag, []pendingEvent, error) {
+	if err := c.taskRepo.RemoveUserTags(taskID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	var own []models.Tag
+	for _, tag := range tags {
+		if tag.UserID == 0 || tag.UserID == userID {
+			own = append(own, tag)
+		}
+	}
+
+	var events []pendingEvent
+	for _, name := range uniqueTags(own) {
+		tag, created, err := c.tagRepo.FindOrCreateByName(userID, name.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if created {
+			events = append(events, pendingEvent{models.EventTagCreated, tag})
+		}
+		if err := c.taskRepo.AddTag(taskID, tag.ID); err != nil {
+			return nil, nil, err
 		}
 	}
 
-	// Return the updated task with tags
-	tags, _ := c.tagRepo.FindByTaskID(updatedTask.ID)
-	ctx.JSON(http.StatusOK, models.UserTask{Task: *updatedTask, Tags: tags})
+	current, err := c.tagRepo.FindByTaskID(taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, events, nil
 }
 
 func (c *TaskController) DeleteTask(ctx *gin.Context) {
@@ -236,8 +597,15 @@ func (c *TaskController) DeleteTask(ctx *gin.Context) {
 		return
 	}
 
+	// Subtasks are moved up to the deleted task's parent unless a cascade is requested
+	children := ctx.DefaultQuery("children", "reparent")
+	if children != "reparent" && children != "cascade" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid children mode"})
+		return
+	}
+
 	// Verify task exists and belongs to user
-	_, err = c.taskRepo.FindByID(uint(taskID), userID)
+	task, err := c.taskRepo.FindByID(uint(taskID), userID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
@@ -246,30 +614,106 @@ func (c *TaskController) DeleteTask(ctx *gin.Context) {
 		}
 		return
 	}
+	if !checkIfMatch(ctx, task) {
+		return
+	}
 
-	if err := c.taskRepo.Delete(uint(taskID)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting task"})
+	// A cascade deletes the whole subtree, which is reported task by task
+	deleted := []models.Task{*task}
+	if children == "cascade" {
+		descendants, err := c.taskRepo.FindDescendants([]uint{task.ID}, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving subtasks"})
+			return
+		}
+		deleted = append(deleted, descendants...)
+	}
+
+	err = c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		txc := c.withTx(tx)
+		if err := txc.taskRepo.Delete(task.ID, task.Version, children == "cascade"); err != nil {
+			return err
+		}
+		for i := range deleted {
+			if err := recordHistory(txc.historyRepo, userCtx, deleted[i].ID, models.TaskHistory{Action: models.HistoryDeleted}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeTaskError(ctx, err, "Error deleting task")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	for i := range deleted {
+		c.dispatcher.Emit(userID, models.EventTaskDeleted, &deleted[i])
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task moved to trash"})
 }
 
-func (c *TaskController) GetTags(ctx *gin.Context) {
-	reqCtx, exists := ctx.Get("requestContext")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
-		return
+// enrich fills in the fields of a task response that are derived from other
+// tasks the user can see: subtask progress and dependencies.
+func (c *TaskController) enrich(userTasks []models.UserTask, userID uint) error {
+	if len(userTasks) == 0 {
+		return nil
 	}
+	if err := c.withProgress(userTasks, userID); err != nil {
+		return err
+	}
+	return c.withDependencies(userTasks, userID)
+}
 
-	userCtx := reqCtx.(middlewares.RequestContext)
-	userID := userCtx.UserID
+// emitTagChanges publishes tag.added and tag.removed for the difference
+// between the tags a task had before and after an update.
+func (c *TaskController) emitTagChanges(userID uint, taskID uint, before []models.Tag, after []models.Tag) {
+	had := make(map[uint]bool, len(before))
+	for _, tag := range before {
+		had[tag.ID] = true
+	}
+	has := make(map[uint]bool, len(after))
+	for _, tag := range after {
+		has[tag.ID] = true
+		if !had[tag.ID] {
+			c.dispatcher.Emit(userID, models.EventTagAdded, gin.H{"task_id": taskID, "tag": tag})
+		}
+	}
+	for _, tag := range before {
+		if !has[tag.ID] {
+			c.dispatcher.Emit(userID, models.EventTagRemoved, gin.H{"task_id": taskID, "tag": tag})
+		}
+	}
+}
 
-	tags, err := c.tagRepo.FindByUserID(userID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving tags"})
-		return
+func highlights(task models.Task, terms []string) map[string]string {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string)
+	if snippet := search.Highlight(task.Title, terms); snippet != "" {
+		result["title"] = snippet
 	}
+	if snippet := search.Highlight(task.Description, terms); snippet != "" {
+		result["description"] = snippet
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
 
-	ctx.JSON(http.StatusOK, tags)
-}
\ No newline at end of file
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,557 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	exportBatchSize = 500
+	maxImportBytes  = 10 << 20
+	maxImportRows   = 5000
+	// tagSeparator joins the tag names of a task in the CSV tags column
+	tagSeparator = ";"
+)
+
+var csvColumns = []string{
+	"id", "title", "description", "status", "priority", "due_date", "parent_id",
+	"project_id", "recurrence", "occurrence", "tags", "created_at", "updated_at",
+}
+
+// importRow is a parsed task along with its 1-based position in the upload.
+type importRow struct {
+	number int
+	task   models.Task
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ExportTasks streams every task of the user, with its tags, as CSV or as
+// newline delimited JSON (?format=ndjson).
+func (c *TaskController) ExportTasks(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	format := ctx.DefaultQuery("format", "csv")
+	var write func([]models.Task) error
+	switch format {
+	case "csv":
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(ctx.Writer)
+		headerWritten := false
+		write = func(tasks []models.Task) error {
+			if !headerWritten {
+				if err := w.Write(csvColumns); err != nil {
+					return err
+				}
+				headerWritten = true
+			}
+			for _, task := range tasks {
+				if err := w.Write(csvRecord(task)); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		}
+	case "ndjson":
+		ctx.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(ctx.Writer)
+		write = func(tasks []models.Task) error {
+			for _, task := range tasks {
+				if err := encoder.Encode(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format"})
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+	ctx.Status(http.StatusOK)
+
+	err := c.taskRepo.FindInBatches(userID, exportBatchSize, func(tasks []models.Task) error {
+		if err := write(tasks); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !ctx.Writer.Written() {
+			ctx.Header("Content-Type", "")
+			ctx.Header("Content-Disposition", "")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error exporting tasks"})
+			return
+		}
+		// The status line is gone already, all that is left is to cut the stream short
+		log.Printf("Export of tasks for user %d failed: %v", userID, err)
+		return
+	}
+
+	// An empty CSV export still gets its header row
+	if format == "csv" && !ctx.Writer.Written() {
+		_ = write(nil)
+	}
+}
+
+// ImportTasks creates tasks from a CSV or NDJSON upload in the format written
+// by ExportTasks. Every row is validated first and nothing is imported unless
+// all of them pass; with ?dry_run=true the validation report is all that
+// happens. Parent IDs may refer to other rows by their exported ID.
+func (c *TaskController) ImportTasks(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	format := ctx.Query("format")
+	if format == "" {
+		switch ctx.ContentType() {
+		case "text/csv":
+			format = "csv"
+		case "application/x-ndjson", "application/json":
+			format = "ndjson"
+		}
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+	var rows []importRow
+	var rowErrors []ImportRowError
+	var err error
+	switch format {
+	case "csv":
+		rows, rowErrors, err = parseCSVImport(body)
+	case "ndjson":
+		rows, rowErrors, err = parseNDJSONImport(body)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format"})
+		return
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+		} else {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	result := ImportResult{DryRun: ctx.Query("dry_run") == "true", Total: len(rows) + len(rowErrors)}
+	if result.Total > maxImportRows {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Imports are limited to %d rows", maxImportRows)})
+		return
+	}
+
+	checkErrors, err := c.checkImport(rows, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error validating import"})
+		return
+	}
+	result.Errors = append(rowErrors, checkErrors...)
+
+	if len(result.Errors) > 0 {
+		sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Row < result.Errors[j].Row })
+		if result.DryRun {
+			ctx.JSON(http.StatusOK, result)
+		} else {
+			ctx.JSON(http.StatusUnprocessableEntity, result)
+		}
+		return
+	}
+	result.Errors = []ImportRowError{}
+	if result.DryRun {
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+
+	tasks := make([]models.Task, len(rows))
+	for i, row := range rows {
+		tasks[i] = row.task
+		tasks[i].UserID = userID
+	}
+	var imported []models.Task
+	err = c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		txc := c.withTx(tx)
+		if imported, err = txc.taskRepo.Import(tasks); err != nil {
+			return err
+		}
+		for i := range imported {
+			if err := recordHistory(txc.historyRepo, userCtx, imported[i].ID, models.TaskHistory{Action: models.HistoryCreated}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeTaskError(ctx, err, "Error importing tasks")
+		return
+	}
+	for i := range imported {
+		c.dispatcher.Emit(userID, models.EventTaskCreated, &imported[i])
+	}
+
+	result.Imported = len(imported)
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// checkImport applies the rules of CreateTask to parsed rows, filling in
+// defaults as it goes, and reports the rows that break them.
+func (c *TaskController) checkImport(rows []importRow, userID uint) ([]ImportRowError, error) {
+	var rowErrors []ImportRowError
+
+	exportedIDs := make(map[uint]int)
+	for i, row := range rows {
+		if row.task.ID == 0 {
+			continue
+		}
+		if _, dup := exportedIDs[row.task.ID]; dup {
+			rowErrors = append(rowErrors, ImportRowError{Row: row.number, Error: fmt.Sprintf("duplicate id %d", row.task.ID)})
+			continue
+		}
+		exportedIDs[row.task.ID] = i
+	}
+
+	existingParents := make(map[uint]*models.Task)
+	activeProjects := make(map[uint]bool)
+	workflows := make(map[uint]*models.Workflow)
+	for i := range rows {
+		task := &rows[i].task
+		number := rows[i].number
+
+		if task.Priority == "" {
+			task.Priority = models.PriorityMedium
+		}
+		if task.Occurrence == 0 {
+			task.Occurrence = 1
+		}
+
+		// Projects are not part of an export, so they must exist already
+		if task.ProjectID != nil {
+			active, checked := activeProjects[*task.ProjectID]
+			if !checked {
+				project, err := c.projectRepo.FindByID(*task.ProjectID, userID)
+				if err != nil && err != gorm.ErrRecordNotFound {
+					return nil, err
+				}
+				active = err == nil && project.ArchivedAt == nil && project.Role.Includes(models.RoleEditor)
+				activeProjects[*task.ProjectID] = active
+			}
+			if !active {
+				rowErrors = append(rowErrors, ImportRowError{Row: number, Error: fmt.Sprintf("project %d not found, archived or read-only", *task.ProjectID)})
+				continue
+			}
+		}
+
+		workflow := models.DefaultWorkflow()
+		if task.ProjectID != nil {
+			if workflow = workflows[*task.ProjectID]; workflow == nil {
+				var err error
+				if workflow, err = c.workflowRepo.FindByProjectID(*task.ProjectID); err != nil {
+					return nil, err
+				}
+				workflows[*task.ProjectID] = workflow
+			}
+		}
+		if err := fitStatus(workflow, task, nil); err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: err.Error()})
+			continue
+		}
+		if err := task.Validate(); err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: err.Error()})
+			continue
+		}
+		if err := normalizeRecurrence(task); err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: err.Error()})
+			continue
+		}
+
+		if task.ParentID == nil {
+			continue
+		}
+		if j, inFile := exportedIDs[*task.ParentID]; inFile {
+			if importCycle(rows, exportedIDs, i) {
+				rowErrors = append(rowErrors, ImportRowError{Row: number, Error: "parent_id forms a cycle"})
+			} else if !sameID(task.ProjectID, rows[j].task.ProjectID) {
+				rowErrors = append(rowErrors, ImportRowError{Row: number, Error: "parent task is in another project"})
+			}
+			continue
+		}
+		parent, checked := existingParents[*task.ParentID]
+		if !checked {
+			found, err := c.taskRepo.FindByID(*task.ParentID, userID)
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return nil, err
+			}
+			if err == nil {
+				role, err := c.authorizer.RoleOf(found, userID)
+				if err != nil {
+					return nil, err
+				}
+				if role.Includes(models.RoleEditor) {
+					parent = found
+				}
+			}
+			existingParents[*task.ParentID] = parent
+		}
+		if parent == nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: fmt.Sprintf("parent task %d not found or read-only", *task.ParentID)})
+		} else if !sameID(task.ProjectID, parent.ProjectID) {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: "parent task is in another project"})
+		}
+	}
+
+	return rowErrors, nil
+}
+
+// importCycle reports whether following parent IDs within the upload from
+// row start leads back to a row already visited.
+func importCycle(rows []importRow, exportedIDs map[uint]int, start int) bool {
+	seen := map[int]bool{start: true}
+	for current := start; rows[current].task.ParentID != nil; {
+		next, ok := exportedIDs[*rows[current].task.ParentID]
+		if !ok {
+			return false
+		}
+		if seen[next] {
+			return true
+		}
+		seen[next] = true
+		current = next
+	}
+	return false
+}
+
+func csvRecord(task models.Task) []string {
+	var dueDate, parentID, projectID string
+	if task.DueDate != nil {
+		dueDate = task.DueDate.UTC().Format(time.RFC3339)
+	}
+	if task.ParentID != nil {
+		parentID = strconv.FormatUint(uint64(*task.ParentID), 10)
+	}
+	if task.ProjectID != nil {
+		projectID = strconv.FormatUint(uint64(*task.ProjectID), 10)
+	}
+	tags := make([]string, len(task.Tags))
+	for i, tag := range task.Tags {
+		tags[i] = tag.Name
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(task.ID), 10),
+		task.Title,
+		task.Description,
+		string(task.Status),
+		string(task.Priority),
+		dueDate,
+		parentID,
+		projectID,
+		task.Recurrence,
+		strconv.Itoa(task.Occurrence),
+		strings.Join(tags, tagSeparator),
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// parseCSVImport reads an upload with a header row naming its columns, which
+// may come in any order; only title is required and unknown columns are
+// ignored. Rows that cannot be parsed are reported rather than returned.
+func parseCSVImport(r io.Reader) ([]importRow, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("Import file is empty")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, errors.New("Import file has no title column")
+	}
+
+	var rows []importRow
+	var rowErrors []ImportRowError
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		task, err := taskFromCSV(record, columns)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, importRow{number: number, task: task})
+	}
+	return rows, rowErrors, nil
+}
+
+func taskFromCSV(record []string, columns map[string]int) (models.Task, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	task := models.Task{
+		Title:       field("title"),
+		Description: field("description"),
+		Status:      models.TaskStatus(field("status")),
+		Priority:    models.TaskPriority(strings.ToUpper(field("priority"))),
+		Recurrence:  field("recurrence"),
+	}
+
+	if value := field("id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return task, fmt.Errorf("invalid id %q", value)
+		}
+		task.ID = uint(id)
+	}
+	if value := field("due_date"); value != "" {
+		dueDate, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return task, fmt.Errorf("invalid due_date %q, expected RFC 3339", value)
+		}
+		task.DueDate = &dueDate
+	}
+	if value := field("parent_id"); value != "" {
+		parentID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return task, fmt.Errorf("invalid parent_id %q", value)
+		}
+		id := uint(parentID)
+		task.ParentID = &id
+	}
+	if value := field("project_id"); value != "" {
+		projectID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return task, fmt.Errorf("invalid project_id %q", value)
+		}
+		id := uint(projectID)
+		task.ProjectID = &id
+	}
+	if value := field("occurrence"); value != "" {
+		occurrence, err := strconv.Atoi(value)
+		if err != nil {
+			return task, fmt.Errorf("invalid occurrence %q", value)
+		}
+		task.Occurrence = occurrence
+	}
+	if value := field("tags"); value != "" {
+		for _, name := range strings.Split(value, tagSeparator) {
+			task.Tags = append(task.Tags, models.Tag{Name: strings.TrimSpace(name)})
+		}
+	}
+
+	task.Tags = uniqueTags(task.Tags)
+	return task, nil
+}
+
+// parseNDJSONImport reads one JSON task per line, skipping blank lines.
+func parseNDJSONImport(r io.Reader) ([]importRow, []ImportRowError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxImportBytes)
+
+	var rows []importRow
+	var rowErrors []ImportRowError
+	for number := 0; scanner.Scan(); {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		number++
+
+		var task models.Task
+		if err := json.Unmarshal([]byte(line), &task); err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: number, Error: "invalid JSON"})
+			continue
+		}
+		task.UserID, task.Relevance = 0, 0
+		task.CreatedAt, task.UpdatedAt = time.Time{}, time.Time{}
+		for i := range task.Tags {
+			task.Tags[i] = models.Tag{Name: strings.TrimSpace(task.Tags[i].Name)}
+		}
+		task.Tags = uniqueTags(task.Tags)
+		rows = append(rows, importRow{number: number, task: task})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(rows)+len(rowErrors) == 0 {
+		return nil, nil, errors.New("Import file is empty")
+	}
+	return rows, rowErrors, nil
+}
+
+// uniqueTags drops repeated tag names, which would otherwise link a task to
+// the same tag twice.
+func uniqueTags(tags []models.Tag) []models.Tag {
+	seen := make(map[string]bool)
+	var unique []models.Tag
+	for _, tag := range tags {
+		if seen[tag.Name] {
+			continue
+		}
+		seen[tag.Name] = true
+		unique = append(unique, tag)
+	}
+	return unique
+}
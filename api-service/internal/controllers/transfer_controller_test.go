@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+)
+
+func TestCSVExportReimports(t *testing.T) {
+	due := time.Date(2024, time.June, 1, 9, 0, 0, 0, time.UTC)
+	parentID := uint(3)
+	exported := models.Task{
+		ID:          4,
+		Title:       "Pay rent, \"on time\"",
+		Description: "Standing order\nfrom the joint account",
+		Status:      models.StatusTodo,
+		Priority:    models.PriorityHigh,
+		DueDate:     &due,
+		ParentID:    &parentID,
+		Recurrence:  "FREQ=MONTHLY",
+		Occurrence:  2,
+		Tags:        []models.Tag{{Name: "home"}, {Name: "bills"}},
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvColumns)
+	w.Write(csvRecord(exported))
+	w.Flush()
+
+	rows, rowErrors, err := parseCSVImport(&buf)
+	if err != nil || len(rowErrors) > 0 || len(rows) != 1 {
+		t.Fatalf("parseCSVImport = %d rows, %v, %v", len(rows), rowErrors, err)
+	}
+	got := rows[0].task
+	if got.ID != exported.ID || got.Title != exported.Title || got.Description != exported.Description ||
+		got.Status != exported.Status || got.Priority != exported.Priority || !got.DueDate.Equal(due) ||
+		*got.ParentID != parentID || got.Recurrence != exported.Recurrence || got.Occurrence != exported.Occurrence {
+		t.Errorf("reimported task = %+v, want %+v", got, exported)
+	}
+	if len(got.Tags) != 2 || got.Tags[0].Name != "home" || got.Tags[1].Name != "bills" {
+		t.Errorf("reimported tags = %v, want home and bills", got.Tags)
+	}
+}
+
+func TestCSVImportReportsBadRows(t *testing.T) {
+	upload := "Priority,TITLE,due_date\n" +
+		"low,Water the plants,\n" +
+		"high,Renew passport,next week\n" +
+		"medium,\"Unterminated\n"
+
+	rows, rowErrors, err := parseCSVImport(strings.NewReader(upload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].number != 1 || rows[0].task.Priority != models.PriorityLow {
+		t.Errorf("rows = %+v, want row 1 with priority LOW", rows)
+	}
+	if len(rowErrors) != 2 || rowErrors[0].Row != 2 || rowErrors[1].Row != 3 {
+		t.Errorf("row errors = %+v, want rows 2 and 3", rowErrors)
+	}
+
+	if _, _, err := parseCSVImport(strings.NewReader("id,description\n1,no title\n")); err == nil {
+		t.Error("upload without a title column accepted")
+	}
+}
+
+func TestImportCycle(t *testing.T) {
+	upload := func(csv string) ([]importRow, map[uint]int) {
+		rows, rowErrors, err := parseCSVImport(strings.NewReader("id,title,parent_id\n" + csv))
+		if err != nil || len(rowErrors) > 0 {
+			t.Fatalf("parseCSVImport: %v %v", rowErrors, err)
+		}
+		exportedIDs := make(map[uint]int)
+		for i, row := range rows {
+			exportedIDs[row.task.ID] = i
+		}
+		return rows, exportedIDs
+	}
+
+	// Parents outside the upload end the walk; they are checked against the
+	// user's existing tasks instead
+	rows, ids := upload("1,Plan trip,\n2,Book flights,1\n3,Pick seats,2\n4,Pack,99\n")
+	for start := range rows {
+		if importCycle(rows, ids, start) {
+			t.Errorf("cycle reported from row %d of a tree", start+1)
+		}
+	}
+
+	rows, ids = upload("1,Own parent,1\n")
+	if !importCycle(rows, ids, 0) {
+		t.Error("task that is its own parent not reported")
+	}
+
+	// 4 is not part of the loop between 2 and 3 but leads into it
+	rows, ids = upload("2,A,3\n3,B,2\n4,C,2\n")
+	for start := range rows {
+		if !importCycle(rows, ids, start) {
+			t.Errorf("loop not reported from row %d", start+1)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"taskmango/apisvc/internal/authz"
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TrashController struct {
+	taskRepo    *repositories.TaskRepository
+	historyRepo *repositories.HistoryRepository
+	authorizer  *authz.Authorizer
+	dispatcher  *webhooks.Dispatcher
+	retention   time.Duration
+}
+
+func NewTrashController(taskRepo *repositories.TaskRepository, historyRepo *repositories.HistoryRepository, authorizer *authz.Authorizer,
+	dispatcher *webhooks.Dispatcher, retention time.Duration) *TrashController {
+	return &TrashController{taskRepo: taskRepo, historyRepo: historyRepo, authorizer: authorizer, dispatcher: dispatcher, retention: retention}
+}
+
+// GetTrash lists the deleted tasks the user can see along with when each will be
+// purged for good.
+func (c *TrashController) GetTrash(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	limit := defaultPageLimit
+	if value := ctx.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(parsed, maxPageLimit)
+	}
+
+	tasks, total, err := c.taskRepo.FindTrash(userID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving trash"})
+		return
+	}
+
+	trashed := make([]models.TrashedTask, len(tasks))
+	for i, task := range tasks {
+		deletedAt := task.DeletedAt.Time
+		trashed[i] = models.TrashedTask{Task: task, Tags: task.Tags, DeletedAt: deletedAt, PurgeAt: deletedAt.Add(c.retention)}
+	}
+
+	ctx.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	ctx.JSON(http.StatusOK, trashed)
+}
+
+// RestoreTask brings a task back from the trash, with the subtasks that were
+// deleted together with it.
+func (c *TrashController) RestoreTask(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	taskID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// Restoring is an edit, the trashed task is not reachable by the route checks
+	trashed, err := c.taskRepo.FindTrashedByID(uint(taskID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found in trash"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		}
+		return
+	}
+	role, err := c.authorizer.RoleOf(trashed, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving task"})
+		return
+	}
+	if !role.Includes(models.RoleEditor) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	var restored []models.Task
+	err = c.taskRepo.Transaction(func(tx *gorm.DB) error {
+		if restored, err = c.taskRepo.WithTx(tx).Restore(trashed.ID, userID); err != nil {
+			return err
+		}
+		historyRepo := c.historyRepo.WithTx(tx)
+		for i := range restored {
+			if err := recordHistory(historyRepo, userCtx, restored[i].ID, models.TaskHistory{Action: models.HistoryRestored}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found in trash"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error restoring task"})
+		}
+		return
+	}
+
+	var task *models.Task
+	for i := range restored {
+		c.dispatcher.Emit(userID, models.EventTaskRestored, &restored[i])
+		if restored[i].ID == uint(taskID) {
+			task = &restored[i]
+		}
+	}
+
+	ctx.Header("ETag", taskETag(task))
+	ctx.JSON(http.StatusOK, gin.H{"task": task, "restored": len(restored)})
+}
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+type WebhookController struct {
+	webhookRepo *repositories.WebhookRepository
+}
+
+func NewWebhookController(webhookRepo *repositories.WebhookRepository) *WebhookController {
+	return &WebhookController{webhookRepo: webhookRepo}
+}
+
+func (c *WebhookController) GetWebhooks(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	endpoints, err := c.webhookRepo.FindEndpointsByUserID(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving webhooks"})
+		return
+	}
+
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	ctx.JSON(http.StatusOK, endpoints)
+}
+
+func (c *WebhookController) GetWebhookByID(ctx *gin.Context) {
+	endpoint, ok := c.endpoint(ctx)
+	if !ok {
+		return
+	}
+
+	endpoint.Secret = ""
+	ctx.JSON(http.StatusOK, endpoint)
+}
+
+func (c *WebhookController) CreateWebhook(ctx *gin.Context) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	var webhookReq WebhookRequest
+	if err := ctx.ShouldBindJSON(&webhookReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook data"})
+		return
+	}
+	if !checkWebhookRequest(ctx, webhookReq) {
+		return
+	}
+
+	endpoint := models.WebhookEndpoint{
+		UserID: userID,
+		URL:    webhookReq.URL,
+		Secret: webhooks.NewSecret(),
+		Events: strings.Join(webhookReq.Events, ","),
+		Active: webhookReq.Active == nil || *webhookReq.Active,
+	}
+
+	createdEndpoint, err := c.webhookRepo.CreateEndpoint(endpoint)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating webhook"})
+		return
+	}
+
+	// The secret is returned this once so the receiver can verify signatures
+	ctx.JSON(http.StatusCreated, createdEndpoint)
+}
+
+func (c *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	var webhookReq WebhookRequest
+	if err := ctx.ShouldBindJSON(&webhookReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook data"})
+		return
+	}
+	if !checkWebhookRequest(ctx, webhookReq) {
+		return
+	}
+
+	endpoint, ok := c.endpoint(ctx)
+	if !ok {
+		return
+	}
+
+	endpoint.URL = webhookReq.URL
+	endpoint.Events = strings.Join(webhookReq.Events, ",")
+	if webhookReq.Active != nil {
+		endpoint.Active = *webhookReq.Active
+	}
+
+	updatedEndpoint, err := c.webhookRepo.UpdateEndpoint(*endpoint)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating webhook"})
+		return
+	}
+
+	updatedEndpoint.Secret = ""
+	ctx.JSON(http.StatusOK, updatedEndpoint)
+}
+
+func (c *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	endpoint, ok := c.endpoint(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.webhookRepo.DeleteEndpoint(endpoint.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting webhook"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+func (c *WebhookController) GetDeliveries(ctx *gin.Context) {
+	limit := defaultDeliveryLimit
+	if value := ctx.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(n, maxDeliveryLimit)
+	}
+
+	endpoint, ok := c.endpoint(ctx)
+	if !ok {
+		return
+	}
+
+	deliveries, err := c.webhookRepo.FindDeliveries(endpoint.ID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving deliveries"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver queues a fresh copy of an earlier delivery. The original entry is
+// left untouched so the log keeps every attempt.
+func (c *WebhookController) Redeliver(ctx *gin.Context) {
+	deliveryID, err := strconv.Atoi(ctx.Param("deliveryId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	endpoint, ok := c.endpoint(ctx)
+	if !ok {
+		return
+	}
+
+	delivery, err := c.webhookRepo.FindDeliveryByID(uint(deliveryID), endpoint.ID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving delivery"})
+		}
+		return
+	}
+
+	redelivery, err := c.webhookRepo.CreateDelivery(models.WebhookDelivery{
+		EndpointID: endpoint.ID,
+		Event:      delivery.Event,
+		Payload:    delivery.Payload,
+		Status:     models.DeliveryPending,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error queueing redelivery"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, redelivery)
+}
+
+// endpoint loads the webhook endpoint named in the path, writing the error
+// response when it does not exist or belongs to someone else.
+func (c *WebhookController) endpoint(ctx *gin.Context) (*models.WebhookEndpoint, bool) {
+	reqCtx, exists := ctx.Get("requestContext")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+		return nil, false
+	}
+
+	userCtx := reqCtx.(middlewares.RequestContext)
+	userID := userCtx.UserID
+
+	endpointID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	endpoint, err := c.webhookRepo.FindEndpointByID(uint(endpointID), userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving webhook"})
+		}
+		return nil, false
+	}
+	return endpoint, true
+}
+
+func checkWebhookRequest(ctx *gin.Context, webhookReq WebhookRequest) bool {
+	if !isHTTPURL(webhookReq.URL) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook URL"})
+		return false
+	}
+
+	for _, event := range webhookReq.Events {
+		if event == "*" || slices.Contains(models.WebhookEvents, event) {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(event, ".*"); ok && (prefix == "task" || prefix == "tag") {
+			continue
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown webhook event " + event})
+		return false
+	}
+	return true
+}
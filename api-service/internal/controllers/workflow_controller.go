@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowRequest struct {
+	Statuses    []models.WorkflowStatus     `json:"statuses" binding:"required"`
+	Transitions []models.WorkflowTransition `json:"transitions"`
+}
+
+type WorkflowController struct {
+	workflowRepo *repositories.WorkflowRepository
+}
+
+func NewWorkflowController(workflowRepo *repositories.WorkflowRepository) *WorkflowController {
+	return &WorkflowController{workflowRepo: workflowRepo}
+}
+
+func (c *WorkflowController) GetWorkflow(ctx *gin.Context) {
+	projectID, ok := workflowProject(ctx)
+	if !ok {
+		return
+	}
+
+	workflow, err := c.workflowRepo.FindByProjectID(projectID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving workflow"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workflow)
+}
+
+// UpdateWorkflow replaces the workflow of a project. Statuses tasks are
+// still in cannot be left out.
+func (c *WorkflowController) UpdateWorkflow(ctx *gin.Context) {
+	var workflowReq WorkflowRequest
+	if err := ctx.ShouldBindJSON(&workflowReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow data"})
+		return
+	}
+
+	projectID, ok := workflowProject(ctx)
+	if !ok {
+		return
+	}
+
+	workflow := &models.Workflow{Custom: true, Statuses: workflowReq.Statuses, Transitions: workflowReq.Transitions}
+	for i := range workflow.Statuses {
+		workflow.Statuses[i].Name = models.TaskStatus(strings.TrimSpace(string(workflow.Statuses[i].Name)))
+	}
+	for i := range workflow.Transitions {
+		workflow.Transitions[i].From = models.TaskStatus(strings.TrimSpace(string(workflow.Transitions[i].From)))
+		workflow.Transitions[i].To = models.TaskStatus(strings.TrimSpace(string(workflow.Transitions[i].To)))
+	}
+	if workflow.Transitions == nil {
+		workflow.Transitions = []models.WorkflowTransition{}
+	}
+	if err := workflow.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range workflow.Transitions {
+		workflow.Transitions[i].From = workflow.Status(workflow.Transitions[i].From).Name
+		workflow.Transitions[i].To = workflow.Status(workflow.Transitions[i].To).Name
+	}
+
+	if !workflowSaved(ctx, c.workflowRepo.Save(projectID, workflow)) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workflow)
+}
+
+// ResetWorkflow puts a project back on the default workflow.
+func (c *WorkflowController) ResetWorkflow(ctx *gin.Context) {
+	projectID, ok := workflowProject(ctx)
+	if !ok {
+		return
+	}
+
+	if !workflowSaved(ctx, c.workflowRepo.Save(projectID, nil)) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.DefaultWorkflow())
+}
+
+func workflowProject(ctx *gin.Context) (uint, bool) {
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return 0, false
+	}
+	return uint(projectID), true
+}
+
+// workflowSaved writes the error response for a failed workflow change and
+// reports whether it succeeded.
+func workflowSaved(ctx *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	var inUse *repositories.StatusInUseError
+	if err == gorm.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	} else if errors.As(err, &inUse) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Tasks are still in statuses the workflow leaves out", "statuses": inUse.Statuses})
+	} else {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error saving workflow"})
+	}
+	return false
+}
+
+// workflow returns the workflow tasks of a project follow; tasks outside
+// projects follow the default one. Called through withTx, it keeps the
+// workflow from changing until the transaction ends.
+func (c *TaskController) workflow(projectID *uint) (*models.Workflow, error) {
+	if projectID == nil {
+		return models.DefaultWorkflow(), nil
+	}
+	if err := c.workflowRepo.Hold(*projectID); err != nil {
+		return nil, err
+	}
+	return c.workflowRepo.FindByProjectID(*projectID)
+}
+
+// applyWorkflow checks the status of task against the workflow of its
+// project and fills in the category, see fitStatus. previous is the task as
+// stored, or nil for a new one.
+func (c *TaskController) applyWorkflow(task *models.Task, previous *models.Task) error {
+	workflow, err := c.workflow(task.ProjectID)
+	if err != nil {
+		return err
+	}
+	return fitStatus(workflow, task, previous)
+}
+
+// fitStatus checks that the status of task is part of workflow and, unless
+// the task just changed projects, can be reached from its previous status,
+// then spells it the way the workflow does and fills in the category. A new
+// task without a status starts in the initial one; a task moved in from
+// another project keeps its status if the workflow has it and otherwise
+// takes the one Workflow.Fit picks.
+func fitStatus(workflow *models.Workflow, task *models.Task, previous *models.Task) error {
+	moving := previous != nil && !sameID(previous.ProjectID, task.ProjectID)
+	if previous == nil && task.Status == "" {
+		task.Status = workflow.Initial().Name
+	} else if moving && task.Status == previous.Status {
+		task.Status = workflow.Fit(task.Status, previous.Category).Name
+	}
+
+	status := workflow.Status(task.Status)
+	if status == nil {
+		return &taskError{http.StatusBadRequest, fmt.Sprintf("Status %s is not part of the workflow", task.Status)}
+	}
+	if previous != nil && !moving && !workflow.Allows(previous.Status, task.Status) {
+		return &taskError{http.StatusConflict, fmt.Sprintf("Tasks cannot move from %s to %s", previous.Status, status.Name)}
+	}
+	task.Status, task.Category = status.Name, status.Category
+	return nil
+}
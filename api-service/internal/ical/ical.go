@@ -0,0 +1,114 @@
+// Package ical writes iCalendar (RFC 5545) documents. It covers what task
+// feeds need: a VCALENDAR holding VTODO or VEVENT components with text, date
+// and list properties, escaped and folded as the RFC requires.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be, excluding the CRLF.
+const maxLineOctets = 75
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Property is a single content line such as "SUMMARY:Buy milk". Value must
+// already be in its iCalendar form; use the helpers below to build one.
+type Property struct {
+	Name  string
+	Value string
+}
+
+func Text(name, value string) Property {
+	return Property{Name: name, Value: textEscaper.Replace(value)}
+}
+
+// TextList builds a multi-valued text property such as CATEGORIES.
+func TextList(name string, values []string) Property {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = textEscaper.Replace(value)
+	}
+	return Property{Name: name, Value: strings.Join(escaped, ",")}
+}
+
+// DateTime formats t as a UTC date-time.
+func DateTime(name string, t time.Time) Property {
+	return Property{Name: name, Value: t.UTC().Format("20060102T150405Z")}
+}
+
+type Component struct {
+	Name       string
+	Properties []Property
+}
+
+func (c *Component) Add(properties ...Property) {
+	c.Properties = append(c.Properties, properties...)
+}
+
+type Calendar struct {
+	Properties []Property
+	Components []Component
+}
+
+// New starts a calendar with the properties every document needs.
+func New(prodID, name string) *Calendar {
+	return &Calendar{Properties: []Property{
+		{Name: "VERSION", Value: "2.0"},
+		{Name: "PRODID", Value: prodID},
+		{Name: "CALSCALE", Value: "GREGORIAN"},
+		{Name: "METHOD", Value: "PUBLISH"},
+		Text("X-WR-CALNAME", name),
+	}}
+}
+
+func (c *Calendar) Add(component Component) {
+	c.Components = append(c.Components, component)
+}
+
+// Bytes serializes the calendar with CRLF line endings and folded lines.
+func (c *Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	for _, p := range c.Properties {
+		writeLine(&buf, p.Name+":"+p.Value)
+	}
+	for _, component := range c.Components {
+		writeLine(&buf, "BEGIN:"+component.Name)
+		for _, p := range component.Properties {
+			writeLine(&buf, p.Name+":"+p.Value)
+		}
+		writeLine(&buf, "END:"+component.Name)
+	}
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// writeLine folds line into chunks of at most maxLineOctets octets, never
+// splitting a UTF-8 sequence; continuation lines start with a space.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the next line's length
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,71 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestCalendarBytes(t *testing.T) {
+	cal := New("-//TaskMango//Tasks//EN", "Work; personal")
+	todo := Component{Name: "VTODO"}
+	todo.Add(
+		Text("UID", "task-7@taskmango"),
+		DateTime("DUE", time.Date(2024, time.May, 3, 11, 0, 0, 0, time.FixedZone("CEST", 2*60*60))),
+		Text("SUMMARY", "Pay rent, then call C:\\landlord"),
+		Text("DESCRIPTION", "first line\r\nsecond line"),
+		TextList("CATEGORIES", []string{"home", "bills,monthly"}),
+	)
+	cal.Add(todo)
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//TaskMango//Tasks//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Work\; personal`,
+		"BEGIN:VTODO",
+		"UID:task-7@taskmango",
+		"DUE:20240503T090000Z",
+		`SUMMARY:Pay rent\, then call C:\\landlord`,
+		`DESCRIPTION:first line\nsecond line`,
+		`CATEGORIES:home,bills\,monthly`,
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+
+	if got := string(cal.Bytes()); got != want {
+		t.Errorf("Bytes() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Long lines are folded at 75 octets without splitting UTF-8 sequences, and
+// a client that unfolds them gets the original line back.
+func TestLongLinesFold(t *testing.T) {
+	for _, text := range []string{
+		strings.Repeat("a", 75),
+		strings.Repeat("a", 76),
+		strings.Repeat("a", 74) + "é",
+		strings.Repeat("Überprüfung der Ergebnisse – ", 12),
+		strings.Repeat("日本語", 40),
+	} {
+		cal := &Calendar{Properties: []Property{{Name: "X", Value: text}}}
+		doc := strings.TrimSuffix(string(cal.Bytes()), "\r\n")
+
+		for _, line := range strings.Split(doc, "\r\n") {
+			if len(line) > maxLineOctets {
+				t.Errorf("line of %d octets: %q", len(line), line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line splits a UTF-8 sequence: %q", line)
+			}
+		}
+
+		unfolded := strings.ReplaceAll(doc, "\r\n ", "")
+		if want := "BEGIN:VCALENDAR\r\nX:" + text + "\r\nEND:VCALENDAR"; unfolded != want {
+			t.Errorf("unfolded document = %q, want %q", unfolded, want)
+		}
+	}
+}
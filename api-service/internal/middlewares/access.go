@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"taskmango/apisvc/internal/authz"
+	"taskmango/apisvc/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RequireTaskRole only lets requests through whose user holds at least role
+// on the task named by the :id path parameter. Tasks the user cannot see are
+// reported as not found.
+func RequireTaskRole(authorizer *authz.Authorizer, role models.ProjectRole) gin.HandlerFunc {
+	return requireRole(role, "Task", authorizer.TaskRole)
+}
+
+// RequireProjectRole only lets requests through whose user holds at least
+// role in the project named by the :id path parameter. Projects the user is
+// not a member of are reported as not found.
+func RequireProjectRole(authorizer *authz.Authorizer, role models.ProjectRole) gin.HandlerFunc {
+	return requireRole(role, "Project", func(projectID uint, userID uint) (models.ProjectRole, error) {
+		granted, err := authorizer.ProjectRole(projectID, userID)
+		if err == nil && granted == "" {
+			err = gorm.ErrRecordNotFound
+		}
+		return granted, err
+	})
+}
+
+// GrantedRole returns the role a RequireTaskRole or RequireProjectRole check
+// found the user to hold.
+func GrantedRole(c *gin.Context) models.ProjectRole {
+	role, _ := c.Get("grantedRole")
+	granted, _ := role.(models.ProjectRole)
+	return granted
+}
+
+// requireRole checks the role lookup finds for the :id path parameter.
+// resource names what the parameter identifies in error messages.
+func requireRole(role models.ProjectRole, resource string, lookup func(id uint, userID uint) (models.ProjectRole, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqCtx, exists := c.Get("requestContext")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
+			return
+		}
+		userID := reqCtx.(RequestContext).UserID
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid " + strings.ToLower(resource) + " ID"})
+			return
+		}
+
+		granted, err := lookup(uint(id), userID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": resource + " not found"})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Error retrieving " + strings.ToLower(resource)})
+			}
+			return
+		}
+		if !granted.Includes(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+
+		c.Set("grantedRole", granted)
+		c.Next()
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Attachment describes a file attached to a task. The contents live in blob
+// storage under StorageKey; ContentType is sniffed from the contents rather
+// than taken from the client.
+type Attachment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TaskID      uint      `gorm:"not null" json:"task_id"`
+	UserID      uint      `gorm:"not null" json:"user_id"`
+	Filename    string    `gorm:"not null" json:"filename"`
+	ContentType string    `gorm:"not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CalendarFeed gives calendar apps read access to a user's tasks through a
+// secret URL, since they cannot present a JWT. Rotating the token revokes the
+// old URL. The token is only ever shown when it is issued.
+type CalendarFeed struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;unique" json:"user_id"`
+	Token     string    `gorm:"not null;unique" json:"-"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
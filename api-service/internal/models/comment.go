@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxCommentLength caps the Markdown source of a comment, in characters.
+const MaxCommentLength = 10000
+
+// TaskComment is a message in the discussion of a task. Body holds Markdown
+// source, which is stored as written and rendered by clients. EditedAt is set
+// once the body has been changed after posting.
+type TaskComment struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TaskID    uint       `gorm:"not null" json:"task_id"`
+	AuthorID  uint       `gorm:"not null" json:"author_id"`
+	Author    string     `gorm:"not null" json:"author"`
+	Body      string     `gorm:"not null" json:"body"`
+	EditedAt  *time.Time `json:"edited_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type CommentPage struct {
+	Comments   []TaskComment `json:"comments"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// Validate checks the rules every stored comment must satisfy.
+func (c *TaskComment) Validate() error {
+	if strings.TrimSpace(c.Body) == "" {
+		return errors.New("body is required")
+	}
+	if utf8.RuneCountInString(c.Body) > MaxCommentLength {
+		return fmt.Errorf("body is longer than %d characters", MaxCommentLength)
+	}
+	return nil
+}
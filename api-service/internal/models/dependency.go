@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TaskDependency records that TaskID cannot be completed before DependsOnID.
+type TaskDependency struct {
+	TaskID      uint      `gorm:"primaryKey" json:"task_id"`
+	DependsOnID uint      `gorm:"primaryKey" json:"depends_on_id"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+type TaskDependencies struct {
+	BlockedBy []Task `json:"blocked_by"`
+	Blocking  []Task `json:"blocking"`
+}
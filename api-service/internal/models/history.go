@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+type HistoryAction string
+
+const (
+	HistoryCreated    HistoryAction = "created"
+	HistoryUpdated    HistoryAction = "updated"
+	HistoryTagAdded   HistoryAction = "tag_added"
+	HistoryTagRemoved HistoryAction = "tag_removed"
+	HistoryAssigned   HistoryAction = "assigned"
+	HistoryUnassigned HistoryAction = "unassigned"
+	HistoryDeleted    HistoryAction = "deleted"
+	HistoryRestored   HistoryAction = "restored"
+)
+
+// TaskHistory is one entry of a task's append-only activity log. Updates are
+// recorded field by field, with the values before and after the change; a
+// nil value stands for a field that was not set.
+type TaskHistory struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	TaskID    uint          `gorm:"not null" json:"task_id"`
+	ActorID   uint          `gorm:"not null" json:"actor_id"`
+	Actor     string        `json:"actor"`
+	Action    HistoryAction `gorm:"not null" json:"action"`
+	Field     string        `json:"field,omitempty"`
+	OldValue  *string       `json:"old_value"`
+	NewValue  *string       `json:"new_value"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// DiffTask returns an update entry for every field that differs between two
+// versions of a task. Tags are not compared.
+func DiffTask(before Task, after Task) []TaskHistory {
+	var changes []TaskHistory
+	change := func(field string, old *string, new *string) {
+		if old == nil && new == nil || old != nil && new != nil && *old == *new {
+			return
+		}
+		changes = append(changes, TaskHistory{Action: HistoryUpdated, Field: field, OldValue: old, NewValue: new})
+	}
+
+	change("title", historyText(before.Title), historyText(after.Title))
+	change("description", historyText(before.Description), historyText(after.Description))
+	change("status", historyText(string(before.Status)), historyText(string(after.Status)))
+	change("priority", historyText(string(before.Priority)), historyText(string(after.Priority)))
+	change("due_date", historyTime(before.DueDate), historyTime(after.DueDate))
+	change("parent_id", historyID(before.ParentID), historyID(after.ParentID))
+	change("project_id", historyID(before.ProjectID), historyID(after.ProjectID))
+	change("recurrence", historyText(before.Recurrence), historyText(after.Recurrence))
+	return changes
+}
+
+// DiffTags returns an entry for every tag added to or removed from a task.
+func DiffTags(before []Tag, after []Tag) []TaskHistory {
+	had := make(map[string]bool, len(before))
+	for _, tag := range before {
+		had[tag.Name] = true
+	}
+	has := make(map[string]bool, len(after))
+	var changes []TaskHistory
+	for _, tag := range after {
+		has[tag.Name] = true
+		if !had[tag.Name] {
+			changes = append(changes, TaskHistory{Action: HistoryTagAdded, Field: "tags", NewValue: historyText(tag.Name)})
+		}
+	}
+	for _, tag := range before {
+		if !has[tag.Name] {
+			changes = append(changes, TaskHistory{Action: HistoryTagRemoved, Field: "tags", OldValue: historyText(tag.Name)})
+		}
+	}
+	return changes
+}
+
+// DiffAssignees returns an entry for every user assigned to or unassigned
+// from a task.
+func DiffAssignees(before []User, after []User) []TaskHistory {
+	had := make(map[uint]bool, len(before))
+	for _, user := range before {
+		had[user.ID] = true
+	}
+	has := make(map[uint]bool, len(after))
+	var changes []TaskHistory
+	for _, user := range after {
+		has[user.ID] = true
+		if !had[user.ID] {
+			changes = append(changes, TaskHistory{Action: HistoryAssigned, Field: "assignees", NewValue: historyText(user.Username)})
+		}
+	}
+	for _, user := range before {
+		if !has[user.ID] {
+			changes = append(changes, TaskHistory{Action: HistoryUnassigned, Field: "assignees", OldValue: historyText(user.Username)})
+		}
+	}
+	return changes
+}
+
+func historyText(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func historyTime(value *time.Time) *string {
+	if value == nil {
+		return nil
+	}
+	return historyText(value.UTC().Format(time.RFC3339))
+}
+
+func historyID(value *uint) *string {
+	if value == nil {
+		return nil
+	}
+	return historyText(strconv.FormatUint(uint64(*value), 10))
+}
@@ -1,9 +1,18 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
 )
 
+// TaskStatus is one of the statuses of the workflow the task follows; the
+// constants are those of the default workflow.
 type TaskStatus string
 type TaskPriority string
 
@@ -18,33 +27,167 @@ const (
 )
 
 type Task struct {
-	ID          uint         `gorm:"primaryKey" json:"id"`
-	Title       string       `gorm:"not null" json:"title"`
-	Description string       `json:"description,omitempty"`
-	Status      TaskStatus   `gorm:"type:enum('TODO','IN_PROGRESS','COMPLETED');default:'TODO'" json:"status"`
-	DueDate     *time.Time   `json:"due_date,omitempty"`
-	Priority    TaskPriority `gorm:"type:enum('LOW','MEDIUM','HIGH');default:'MEDIUM'" json:"priority"`
-	UserID      uint         `gorm:"not null" json:"user_id"`
-	CreatedAt   time.Time    `json:"created_at,omitempty"`
-	UpdatedAt   time.Time    `json:"updated_at,omitempty"`
-	Tags        []Tag        `gorm:"many2many:task_tags;" json:"tags,omitempty"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Title       string         `gorm:"not null" json:"title"`
+	Description string         `json:"description,omitempty"`
+	Status      TaskStatus     `gorm:"not null" json:"status"`
+	Category    StatusCategory `gorm:"column:status_category;not null" json:"status_category"`
+	DueDate     *time.Time     `json:"due_date,omitempty"`
+	Priority    TaskPriority   `gorm:"type:enum('LOW','MEDIUM','HIGH');default:'MEDIUM'" json:"priority"`
+	UserID      uint           `gorm:"not null" json:"user_id"`
+	ParentID    *uint          `json:"parent_id,omitempty"`
+	ProjectID   *uint          `json:"project_id,omitempty"`
+	Recurrence  string         `json:"recurrence,omitempty"`
+	Occurrence  int            `gorm:"default:1" json:"occurrence,omitempty"`
+	// NextOccurrenceID is the task spawned when this occurrence was completed
+	NextOccurrenceID *uint          `json:"-"`
+	Version          uint           `gorm:"not null;default:1" json:"version"`
+	CreatedAt        time.Time      `json:"created_at,omitempty"`
+	UpdatedAt        time.Time      `json:"updated_at,omitempty"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+	Tags             []Tag          `gorm:"many2many:task_tags;" json:"tags,omitempty"`
+	Assignees        []User         `gorm:"many2many:task_assignees;" json:"assignees,omitempty"`
+	Relevance        float64        `gorm:"-" json:"relevance,omitempty"`
 }
 
+// MaxTitleLength matches the width of the tasks.title column.
+const MaxTitleLength = 255
+
+// Validate checks the rules every stored task must satisfy. Defaults for
+// status and priority are expected to have been applied already; whether the
+// status belongs to the task's workflow is not checked here.
+func (t *Task) Validate() error {
+	if strings.TrimSpace(t.Title) == "" {
+		return errors.New("title is required")
+	}
+	if utf8.RuneCountInString(t.Title) > MaxTitleLength {
+		return fmt.Errorf("title is longer than %d characters", MaxTitleLength)
+	}
+	if strings.TrimSpace(string(t.Status)) == "" {
+		return errors.New("status is required")
+	}
+	if utf8.RuneCountInString(string(t.Status)) > MaxStatusLength {
+		return fmt.Errorf("status is longer than %d characters", MaxStatusLength)
+	}
+	switch t.Priority {
+	case PriorityLow, PriorityMedium, PriorityHigh:
+	default:
+		return fmt.Errorf("invalid priority %q", t.Priority)
+	}
+	if t.Occurrence < 1 {
+		return errors.New("occurrence must be positive")
+	}
+	for _, tag := range t.Tags {
+		if err := CheckTagName(tag.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TagSeparator divides a tag name into a path, like in "work/clientA/billing".
+// A tag sits below the tags named by the leading parts of its path, whether
+// those exist or not.
+const TagSeparator = "/"
+
+// Tag is a label of one user. Names are unique per user; on a task shared
+// through a project every member tags it from their own set.
 type Tag struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"unique;not null" json:"name"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null" json:"user_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Color       string    `json:"color,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// MaxTagNameLength matches the width of the tags.name column.
+const MaxTagNameLength = 50
+
+var tagColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Validate checks the rules every stored tag must satisfy. Colors are given
+// as #rrggbb.
+func (t *Tag) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("name is required")
+	}
+	if err := CheckTagName(t.Name); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(t.Name) > MaxTagNameLength {
+		return fmt.Errorf("name is longer than %d characters", MaxTagNameLength)
+	}
+	if t.Color != "" && !tagColor.MatchString(t.Color) {
+		return fmt.Errorf("invalid color %q", t.Color)
+	}
+	return nil
+}
+
+// CheckTagName verifies that name is a path of non-empty parts.
+func CheckTagName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("tag names cannot be empty")
+	}
+	for _, part := range strings.Split(name, TagSeparator) {
+		if strings.TrimSpace(part) == "" {
+			return fmt.Errorf("tag %q has an empty path segment", name)
+		}
+	}
+	return nil
+}
+
+// TagNode is one level of the user's tags arranged by path. Tag is nil for a
+// level no tag is named after, such as "work" when only "work/billing"
+// exists. TaskCount counts the tasks carrying the tag or any tag below it.
+type TagNode struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Tag       *Tag      `json:"tag,omitempty"`
+	TaskCount int       `json:"task_count"`
+	Children  []TagNode `json:"children,omitempty"`
 }
 
 type UserTask struct {
-	Task Task  `json:"task"`
-	Tags []Tag `json:"tags,omitempty"`
+	Task           Task              `json:"task"`
+	Tags           []Tag             `json:"tags,omitempty"`
+	Assignees      []User            `json:"assignees,omitempty"`
+	Highlights     map[string]string `json:"highlights,omitempty"`
+	Progress       *int              `json:"progress,omitempty"`
+	Subtasks       []UserTask        `json:"subtasks,omitempty"`
+	BlockedBy      []uint            `json:"blocked_by,omitempty"`
+	Blocking       []uint            `json:"blocking,omitempty"`
+	NextOccurrence *Task             `json:"next_occurrence,omitempty"`
 }
 
 type TaskFilter struct {
-	Status        TaskStatus   `form:"status"`
-	Priority      TaskPriority `form:"priority"`
-	DueDateBefore string       `form:"due_date_before"`
-	DueDateAfter  string       `form:"due_date_after"`
-	TagName       string       `form:"tagName"`
-}
\ No newline at end of file
+	Status        TaskStatus     `form:"status" json:"status"`
+	Category      StatusCategory `form:"category" json:"category"`
+	Priority      TaskPriority   `form:"priority" json:"priority"`
+	DueDateBefore string         `form:"due_date_before" json:"due_date_before"`
+	DueDateAfter  string         `form:"due_date_after" json:"due_date_after"`
+	TagName       string         `form:"tagName" json:"tag_name"`
+	Query         string         `form:"q" json:"q"`
+	ParentID      string         `form:"parent_id" json:"parent_id"`
+	ProjectID     string         `form:"project_id" json:"project_id"`
+	Assignee      string         `form:"assignee" json:"assignee"`
+}
+
+type Pagination struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+}
+
+type TaskPage struct {
+	Tasks      []UserTask `json:"tasks"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// TrashedTask is a deleted task waiting in the trash until it is purged.
+type TrashedTask struct {
+	Task      Task      `json:"task"`
+	Tags      []Tag     `json:"tags,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTagValidate(t *testing.T) {
+	valid := []Tag{
+		{Name: "home"},
+		{Name: "Groceries", Color: "#a1B2c3"},
+		{Name: strings.Repeat("ü", MaxTagNameLength), Description: "fits in runes, not in bytes"},
+	}
+	for _, tag := range valid {
+		if err := tag.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", tag, err)
+		}
+	}
+
+	invalid := map[string]Tag{
+		"blank name":    {Name: "  "},
+		"long name":     {Name: strings.Repeat("a", MaxTagNameLength+1)},
+		"named color":   {Name: "home", Color: "red"},
+		"short color":   {Name: "home", Color: "#abc"},
+		"missing hash":  {Name: "home", Color: "a1b2c3"},
+		"not hex color": {Name: "home", Color: "#a1b2cg"},
+	}
+	for name, tag := range invalid {
+		if err := tag.Validate(); err == nil {
+			t.Errorf("%s: Validate(%+v) = nil, want an error", name, tag)
+		}
+	}
+}
+
+func TestCheckTagName(t *testing.T) {
+	for _, name := range []string{"home", "work/billing", "work/billing/q1", "a b/c d"} {
+		if err := CheckTagName(name); err != nil {
+			t.Errorf("CheckTagName(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", " ", "/work", "work/", "work//billing", "work/ /billing"} {
+		if err := CheckTagName(name); err == nil {
+			t.Errorf("CheckTagName(%q) = nil, want an error", name)
+		}
+	}
+}
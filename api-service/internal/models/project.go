@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxProjectNameLength matches the width of the projects.name column.
+const MaxProjectNameLength = 255
+
+// ProjectRole is what a member may do in a project. Each role includes the
+// ones below it: viewers read, editors also change tasks, owners also manage
+// the project and its members.
+type ProjectRole string
+
+const (
+	RoleViewer ProjectRole = "viewer"
+	RoleEditor ProjectRole = "editor"
+	RoleOwner  ProjectRole = "owner"
+)
+
+var roleRanks = map[ProjectRole]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Valid reports whether r is one of the known roles.
+func (r ProjectRole) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// Includes reports whether r allows everything required allows. No role,
+// the empty string, includes nothing.
+func (r ProjectRole) Includes(required ProjectRole) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// Project is a list that groups tasks, shared with the users who are members
+// of it. UserID is the user who created it. An archived project keeps its
+// tasks, but they no longer show up unless the project is asked for.
+type Project struct {
+	ID             uint        `gorm:"primaryKey" json:"id"`
+	UserID         uint        `gorm:"not null" json:"user_id"`
+	Name           string      `gorm:"not null" json:"name"`
+	Description    string      `json:"description,omitempty"`
+	ArchivedAt     *time.Time  `json:"archived_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	Role           ProjectRole `gorm:"->" json:"role,omitempty"`
+	TaskCount      int64       `gorm:"-" json:"task_count"`
+	CompletedCount int64       `gorm:"-" json:"completed_count"`
+}
+
+// ProjectMember grants a user a role in a project. Username is read from the
+// users table.
+type ProjectMember struct {
+	ProjectID uint        `gorm:"primaryKey;autoIncrement:false" json:"project_id"`
+	UserID    uint        `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Username  string      `gorm:"->" json:"username"`
+	Role      ProjectRole `gorm:"not null" json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Validate checks the rules every stored project must satisfy.
+func (p *Project) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(p.Name) > MaxProjectNameLength {
+		return fmt.Errorf("name is longer than %d characters", MaxProjectNameLength)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+type ReminderChannel string
+
+const (
+	ChannelWebhook ReminderChannel = "webhook"
+	ChannelEmail   ReminderChannel = "email"
+)
+
+// Reminder fires once at RemindAt. Reminders defined by OffsetMinutes follow
+// the due date of their task and stay unscheduled while it has none.
+type Reminder struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	TaskID        uint            `gorm:"not null" json:"task_id"`
+	UserID        uint            `gorm:"not null" json:"user_id"`
+	RemindAt      *time.Time      `json:"remind_at"`
+	OffsetMinutes *int            `json:"offset_minutes,omitempty"`
+	Channel       ReminderChannel `gorm:"not null" json:"channel"`
+	Target        string          `gorm:"not null" json:"target"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error,omitempty"`
+	FiredAt       *time.Time      `json:"fired_at,omitempty"`
+	ClaimedBy     string          `json:"-"`
+	ClaimedUntil  *time.Time      `json:"-"`
+	CreatedAt     time.Time       `json:"created_at,omitempty"`
+}
+
+// Schedule derives RemindAt from the task due date for offset reminders.
+func (r *Reminder) Schedule(dueDate *time.Time) {
+	if r.OffsetMinutes == nil {
+		return
+	}
+	if dueDate == nil {
+		r.RemindAt = nil
+		return
+	}
+	remindAt := dueDate.Add(-time.Duration(*r.OffsetMinutes) * time.Minute)
+	r.RemindAt = &remindAt
+}
@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+// User is an account of the auth service, which owns the users table. The
+// API only reads it, to find the people projects are shared with.
+type User struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Username  string         `json:"username"`
+	DeletedAt gorm.DeletedAt `json:"-"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	EventTaskCreated  = "task.created"
+	EventTaskUpdated  = "task.updated"
+	EventTaskDeleted  = "task.deleted"
+	EventTaskRestored = "task.restored"
+	EventTagCreated   = "tag.created"
+	EventTagAdded     = "tag.added"
+	EventTagRemoved   = "tag.removed"
+	EventTagUpdated   = "tag.updated"
+	EventTagDeleted   = "tag.deleted"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// WebhookEndpoint receives the events of one user. Events is a comma
+// separated list of event names, where "task.*" matches every task event; an
+// empty list subscribes to everything. The secret is only shown when the
+// endpoint is created.
+type WebhookEndpoint struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"secret,omitempty"`
+	Events    string    `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+var WebhookEvents = []string{
+	EventTaskCreated, EventTaskUpdated, EventTaskDeleted, EventTaskRestored,
+	EventTagCreated, EventTagAdded, EventTagRemoved, EventTagUpdated, EventTagDeleted,
+}
+
+func (e *WebhookEndpoint) Subscribes(event string) bool {
+	if strings.TrimSpace(e.Events) == "" {
+		return true
+	}
+	for _, pattern := range strings.Split(e.Events, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == event || pattern == "*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one attempt to get an event to an endpoint, kept as the
+// delivery log. Payload is the exact body that is signed and sent.
+type WebhookDelivery struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	EndpointID    uint           `gorm:"not null" json:"endpoint_id"`
+	Event         string         `gorm:"not null" json:"event"`
+	Payload       string         `gorm:"type:text;not null" json:"payload"`
+	Status        DeliveryStatus `gorm:"default:'pending'" json:"status"`
+	Attempts      int            `json:"attempts"`
+	ResponseCode  *int           `json:"response_code,omitempty"`
+	LastError     string         `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty"`
+	ClaimedBy     string         `json:"-"`
+	ClaimedUntil  *time.Time     `json:"-"`
+	CreatedAt     time.Time      `json:"created_at,omitempty"`
+
+	Endpoint *WebhookEndpoint `gorm:"foreignKey:EndpointID" json:"-"`
+}
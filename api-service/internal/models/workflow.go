@@ -0,0 +1,182 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxStatusLength matches the width of the tasks.status column.
+const MaxStatusLength = 50
+
+// maxWorkflowStatuses bounds how many statuses one workflow may have.
+const maxWorkflowStatuses = 20
+
+// StatusCategory says how far along a task in a status is. Everything that
+// depends on a task being finished, like progress, dependencies and
+// recurrence, goes by the category of its status.
+type StatusCategory string
+
+const (
+	CategoryTodo  StatusCategory = "todo"
+	CategoryDoing StatusCategory = "doing"
+	CategoryDone  StatusCategory = "done"
+)
+
+// StatusCategories lists the categories in the order tasks go through them.
+var StatusCategories = []StatusCategory{CategoryTodo, CategoryDoing, CategoryDone}
+
+// Valid reports whether c is one of the known categories.
+func (c StatusCategory) Valid() bool {
+	switch c {
+	case CategoryTodo, CategoryDoing, CategoryDone:
+		return true
+	}
+	return false
+}
+
+// WorkflowStatus is one of the statuses of a project's workflow. Position
+// orders them like the columns of a board.
+type WorkflowStatus struct {
+	ProjectID uint           `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Name      TaskStatus     `gorm:"primaryKey" json:"name"`
+	Category  StatusCategory `gorm:"not null" json:"category"`
+	Position  int            `gorm:"not null" json:"-"`
+}
+
+// WorkflowTransition lets tasks of a project move from one status to another.
+type WorkflowTransition struct {
+	ProjectID uint       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	From      TaskStatus `gorm:"column:from_status;primaryKey" json:"from"`
+	To        TaskStatus `gorm:"column:to_status;primaryKey" json:"to"`
+}
+
+// Workflow is the ordered statuses the tasks of a project go through. New
+// tasks start in the first one. Without transitions a task may move between
+// any two statuses; otherwise only the listed moves are allowed. Like in the
+// database, status names are told apart regardless of case. Projects that
+// never defined one, and tasks outside projects, follow DefaultWorkflow.
+type Workflow struct {
+	Custom      bool                 `json:"custom"`
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// DefaultWorkflow returns the workflow of TODO, IN_PROGRESS and COMPLETED.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []WorkflowStatus{
+			{Name: StatusTodo, Category: CategoryTodo},
+			{Name: StatusInProgress, Category: CategoryDoing},
+			{Name: StatusCompleted, Category: CategoryDone},
+		},
+		Transitions: []WorkflowTransition{},
+	}
+}
+
+// Validate checks the rules every stored workflow must satisfy.
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.New("a workflow needs at least one status")
+	}
+	if len(w.Statuses) > maxWorkflowStatuses {
+		return fmt.Errorf("a workflow has at most %d statuses", maxWorkflowStatuses)
+	}
+
+	done := false
+	for i, status := range w.Statuses {
+		if strings.TrimSpace(string(status.Name)) == "" {
+			return errors.New("status names cannot be empty")
+		}
+		if utf8.RuneCountInString(string(status.Name)) > MaxStatusLength {
+			return fmt.Errorf("status %q is longer than %d characters", status.Name, MaxStatusLength)
+		}
+		if !status.Category.Valid() {
+			return fmt.Errorf("invalid category %q for status %q", status.Category, status.Name)
+		}
+		for _, other := range w.Statuses[:i] {
+			if sameStatus(other.Name, status.Name) {
+				return fmt.Errorf("status %q is listed twice", status.Name)
+			}
+		}
+		done = done || status.Category == CategoryDone
+	}
+	if !done {
+		return errors.New("a workflow needs a status in the done category")
+	}
+
+	for i, transition := range w.Transitions {
+		if w.Status(transition.From) == nil {
+			return fmt.Errorf("transition from unknown status %q", transition.From)
+		}
+		if w.Status(transition.To) == nil {
+			return fmt.Errorf("transition to unknown status %q", transition.To)
+		}
+		if sameStatus(transition.From, transition.To) {
+			return fmt.Errorf("transition from %q to itself", transition.From)
+		}
+		for _, other := range w.Transitions[:i] {
+			if sameStatus(other.From, transition.From) && sameStatus(other.To, transition.To) {
+				return fmt.Errorf("transition from %q to %q is listed twice", transition.From, transition.To)
+			}
+		}
+	}
+	return nil
+}
+
+// Status returns the status of the workflow with the given name, or nil.
+func (w *Workflow) Status(name TaskStatus) *WorkflowStatus {
+	for i := range w.Statuses {
+		if sameStatus(w.Statuses[i].Name, name) {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// Initial returns the status new tasks start in.
+func (w *Workflow) Initial() WorkflowStatus {
+	return w.Statuses[0]
+}
+
+// Allows reports whether a task may move from one status to another.
+func (w *Workflow) Allows(from TaskStatus, to TaskStatus) bool {
+	if sameStatus(from, to) || len(w.Transitions) == 0 {
+		return true
+	}
+	for _, transition := range w.Transitions {
+		if sameStatus(transition.From, from) && sameStatus(transition.To, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fit returns the status a task in status, of category, takes on joining the
+// workflow: the same one if the workflow has it, otherwise the first status
+// of the same category, or the initial status when there is none.
+func (w *Workflow) Fit(status TaskStatus, category StatusCategory) WorkflowStatus {
+	if own := w.Status(status); own != nil {
+		return *own
+	}
+	for _, candidate := range w.Statuses {
+		if candidate.Category == category {
+			return candidate
+		}
+	}
+	return w.Initial()
+}
+
+// StatusNames returns the names of the statuses in order.
+func (w *Workflow) StatusNames() []TaskStatus {
+	names := make([]TaskStatus, len(w.Statuses))
+	for i, status := range w.Statuses {
+		names[i] = status.Name
+	}
+	return names
+}
+
+func sameStatus(a TaskStatus, b TaskStatus) bool {
+	return strings.EqualFold(string(a), string(b))
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// reviewWorkflow has a review step that only in-progress tasks may enter.
+func reviewWorkflow() *Workflow {
+	return &Workflow{
+		Custom: true,
+		Statuses: []WorkflowStatus{
+			{Name: "Backlog", Category: CategoryTodo},
+			{Name: "Doing", Category: CategoryDoing},
+			{Name: "Review", Category: CategoryDoing},
+			{Name: "Done", Category: CategoryDone},
+		},
+		Transitions: []WorkflowTransition{
+			{From: "Backlog", To: "Doing"},
+			{From: "Doing", To: "Review"},
+			{From: "Review", To: "Doing"},
+			{From: "Review", To: "Done"},
+		},
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	t.Run("default and custom", func(t *testing.T) {
+		for _, workflow := range []*Workflow{DefaultWorkflow(), reviewWorkflow()} {
+			if err := workflow.Validate(); err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+		}
+	})
+
+	// Each case breaks an otherwise valid workflow in one way
+	broken := map[string]struct {
+		change  func(w *Workflow)
+		wantErr string
+	}{
+		"no statuses": {func(w *Workflow) { w.Statuses, w.Transitions = nil, nil }, "at least one status"},
+		"too many statuses": {func(w *Workflow) {
+			for i := len(w.Statuses); i <= maxWorkflowStatuses; i++ {
+				w.Statuses = append(w.Statuses, WorkflowStatus{Name: TaskStatus("Step " + strings.Repeat("I", i)), Category: CategoryDoing})
+			}
+		}, "at most"},
+		"blank name":       {func(w *Workflow) { w.Statuses[1].Name = " " }, "cannot be empty"},
+		"long name":        {func(w *Workflow) { w.Statuses[1].Name = TaskStatus(strings.Repeat("a", MaxStatusLength+1)) }, "longer than"},
+		"unknown category": {func(w *Workflow) { w.Statuses[1].Category = "finished" }, "invalid category"},
+		"duplicate status": {func(w *Workflow) { w.Statuses[2].Name = "DOING" }, "listed twice"},
+		"no done status":   {func(w *Workflow) { w.Statuses[3].Category = CategoryDoing }, "done category"},
+		"from unknown":     {func(w *Workflow) { w.Transitions[0].From = "Blocked" }, "from unknown"},
+		"to unknown":       {func(w *Workflow) { w.Transitions[0].To = "Blocked" }, "to unknown"},
+		"to itself":        {func(w *Workflow) { w.Transitions[0].To = "backlog" }, "to itself"},
+		"duplicate transition": {func(w *Workflow) {
+			w.Transitions = append(w.Transitions, WorkflowTransition{From: "review", To: "DONE"})
+		}, "listed twice"},
+	}
+	for name, tc := range broken {
+		t.Run(name, func(t *testing.T) {
+			workflow := reviewWorkflow()
+			tc.change(workflow)
+			err := workflow.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Validate() = %v, want an error about %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// A task walks through the review workflow, trying shortcuts on the way.
+func TestWorkflowAllowsReviewPath(t *testing.T) {
+	workflow := reviewWorkflow()
+	steps := []struct {
+		from, to TaskStatus
+		allowed  bool
+	}{
+		{"Backlog", "Done", false},
+		{"Backlog", "Review", false},
+		{"backlog", "DOING", true},
+		{"Doing", "Doing", true},
+		{"Doing", "Done", false},
+		{"Doing", "Review", true},
+		{"Review", "Doing", true},
+		{"Review", "Done", true},
+		{"Done", "Backlog", false},
+	}
+	for _, step := range steps {
+		if got := workflow.Allows(step.from, step.to); got != step.allowed {
+			t.Errorf("%s → %s allowed = %t, want %t", step.from, step.to, got, step.allowed)
+		}
+	}
+
+	// Without transitions every move is allowed
+	if !DefaultWorkflow().Allows(StatusCompleted, StatusTodo) {
+		t.Error("default workflow refuses to reopen a completed task")
+	}
+}
+
+func TestWorkflowFit(t *testing.T) {
+	workflow := reviewWorkflow()
+
+	// Statuses of the workflow are matched by name, ignoring case
+	if got := workflow.Fit("review", CategoryDoing); got.Name != "Review" {
+		t.Errorf("Fit(review) = %s, want Review", got.Name)
+	}
+	// Others, such as those of the default workflow, go by category
+	for status, want := range map[TaskStatus]TaskStatus{
+		StatusTodo:       "Backlog",
+		StatusInProgress: "Doing",
+		StatusCompleted:  "Done",
+	} {
+		if got := workflow.Fit(status, DefaultWorkflow().Status(status).Category); got.Name != want {
+			t.Errorf("Fit(%s) = %s, want %s", status, got.Name, want)
+		}
+	}
+
+	// A category the workflow lacks falls back to the initial status
+	twoStep := &Workflow{Statuses: []WorkflowStatus{{Name: "Open", Category: CategoryTodo}, {Name: "Closed", Category: CategoryDone}}}
+	if got := twoStep.Fit(StatusInProgress, CategoryDoing); got.Name != "Open" {
+		t.Errorf("Fit without a doing status = %s, want Open", got.Name)
+	}
+}
@@ -0,0 +1,32 @@
+// Package notifiers delivers reminders to the outside world. Each delivery
+// channel implements Notifier; the scheduler picks one by the channel stored
+// on the reminder.
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+)
+
+type Notification struct {
+	Reminder models.Reminder
+	Task     models.Task
+}
+
+func (n Notification) Subject() string {
+	return fmt.Sprintf("Reminder: %s", n.Task.Title)
+}
+
+func (n Notification) Body() string {
+	if n.Task.DueDate == nil {
+		return fmt.Sprintf("This is a reminder about your task %q.", n.Task.Title)
+	}
+	return fmt.Sprintf("Your task %q is due %s.", n.Task.Title, n.Task.DueDate.Format(time.RFC1123))
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, target string, notification Notification) error
+}
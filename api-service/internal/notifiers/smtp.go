@@ -0,0 +1,106 @@
+package notifiers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headerSafe keeps user supplied text such as task titles from starting new
+// header lines.
+var headerSafe = strings.NewReplacer("\r", " ", "\n", " ")
+
+// SMTPNotifier sends reminders as plain text email. Authentication is only
+// attempted when a username is configured, so it can talk to unauthenticated
+// local relays such as MailHog.
+type SMTPNotifier struct {
+	host     string
+	port     int
+	from     string
+	username string
+	password string
+}
+
+func NewSMTPNotifier(host string, port int, from, username, password string) *SMTPNotifier {
+	return &SMTPNotifier{host: host, port: port, from: from, username: username, password: password}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, target string, notification Notification) error {
+	msg := strings.Join([]string{
+		"From: " + n.from,
+		"To: " + target,
+		"Subject: " + headerSafe.Replace(notification.Subject()),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		notification.Body(),
+	}, "\r\n")
+
+	err := n.send(ctx, target, []byte(msg))
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("sending mail: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail over a connection that is closed as soon as ctx is
+// done, so that a cancelled reminder is not delivered after all.
+func (n *SMTPNotifier) send(ctx context.Context, target string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(n.host, strconv.Itoa(n.port)))
+	if err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(target); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
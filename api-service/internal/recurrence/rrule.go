@@ -0,0 +1,357 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used
+// by recurring tasks: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY,
+// COUNT and UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxEmptyPeriods bounds the search for the next occurrence of rules such as
+// "the fifth Monday" that can skip periods.
+const maxEmptyPeriods = 1000
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WeekdayNum is a BYDAY entry. N selects the nth (or, when negative, the nth
+// from last) such weekday of the month or year; zero selects all of them.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    *time.Time
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE". A leading
+// "RRULE:" is accepted.
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	rule := &Rule{Interval: 1}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" || seen[name] {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch Frequency(val) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = Frequency(val)
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				wn, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wn)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	}
+	if rule.Freq == Daily || rule.Freq == Weekly {
+		for _, wn := range rule.ByDay {
+			if wn.N != 0 {
+				return nil, fmt.Errorf("%w: numbered BYDAY requires MONTHLY or YEARLY", ErrInvalidRule)
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			if layout == "20060102" {
+				// A bare date includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: malformed UNTIL %q", ErrInvalidRule, value)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: malformed BYDAY %q", ErrInvalidRule, value)
+	}
+
+	weekday, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: malformed BYDAY %q", ErrInvalidRule, value)
+	}
+
+	wn := WeekdayNum{Weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("%w: malformed BYDAY %q", ErrInvalidRule, value)
+		}
+		wn.N = n
+	}
+	return wn, nil
+}
+
+// String formats the rule in canonical RRULE form, without the "RRULE:" prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wn := range r.ByDay {
+			days[i] = wn.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (wn WeekdayNum) String() string {
+	day := strings.ToUpper(wn.Weekday.String()[:2])
+	if wn.N != 0 {
+		return strconv.Itoa(wn.N) + day
+	}
+	return day
+}
+
+// Occurrences returns up to n occurrences of a series starting at dtstart.
+// As in RFC 5545, dtstart is always the first occurrence.
+func (r *Rule) Occurrences(dtstart time.Time, n int) []time.Time {
+	var result []time.Time
+	r.each(dtstart, r.Count, func(t time.Time) bool {
+		result = append(result, t)
+		return len(result) < n
+	})
+	return result
+}
+
+// Next returns the occurrence that follows current, the seq-th (1-based)
+// occurrence of the series, and false once the series has ended.
+func (r *Rule) Next(current time.Time, seq int) (time.Time, bool) {
+	remaining := 0
+	if r.Count > 0 {
+		remaining = r.Count - seq + 1
+		if remaining <= 1 {
+			return time.Time{}, false
+		}
+	}
+
+	var next time.Time
+	found := false
+	r.each(current, remaining, func(t time.Time) bool {
+		if t.After(current) {
+			next, found = t, true
+			return false
+		}
+		return true
+	})
+	return next, found
+}
+
+// each walks the series anchored at dtstart, calling fn for every occurrence
+// until fn returns false, count occurrences have been produced (when count is
+// positive) or UNTIL is passed.
+func (r *Rule) each(dtstart time.Time, count int, fn func(time.Time) bool) {
+	if r.Until != nil && dtstart.After(*r.Until) {
+		return
+	}
+
+	produced := 1
+	if !fn(dtstart) || (count > 0 && produced >= count) {
+		return
+	}
+
+	empty := 0
+	for period := 0; empty < maxEmptyPeriods; period++ {
+		candidates := r.expand(dtstart, period*r.Interval)
+		emitted := false
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return
+			}
+			emitted = true
+			produced++
+			if !fn(t) || (count > 0 && produced >= count) {
+				return
+			}
+		}
+		if emitted {
+			empty = 0
+		} else {
+			empty++
+		}
+	}
+}
+
+// expand lists, in order, the candidate occurrences of the period lying
+// offset frequency units after the one containing dtstart.
+func (r *Rule) expand(dtstart time.Time, offset int) []time.Time {
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hh, mm, ss, dtstart.Nanosecond(), loc)
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+offset)
+		if len(r.ByDay) == 0 || r.matchesWeekday(t.Weekday()) {
+			candidates = append(candidates, t)
+		}
+
+	case Weekly:
+		// Weeks start on Monday, the RFC 5545 default for WKST
+		monday := at(y, m, d-(int(dtstart.Weekday())+6)%7+7*offset)
+		if len(r.ByDay) == 0 {
+			candidates = append(candidates, at(y, m, d+7*offset))
+		}
+		for i := 0; i < 7 && len(r.ByDay) > 0; i++ {
+			t := monday.AddDate(0, 0, i)
+			if r.matchesWeekday(t.Weekday()) {
+				candidates = append(candidates, t)
+			}
+		}
+
+	case Monthly:
+		first := at(y, m+time.Month(offset), 1)
+		if len(r.ByDay) == 0 {
+			if t := at(first.Year(), first.Month(), d); t.Month() == first.Month() {
+				candidates = append(candidates, t)
+			}
+		} else {
+			last := first.AddDate(0, 1, -1)
+			candidates = r.byDayWithin(first, last)
+		}
+
+	case Yearly:
+		year := y + offset
+		if len(r.ByDay) == 0 {
+			if t := at(year, m, d); t.Month() == m {
+				candidates = append(candidates, t)
+			}
+		} else {
+			candidates = r.byDayWithin(at(year, time.January, 1), at(year, time.December, 31))
+		}
+	}
+
+	return candidates
+}
+
+func (r *Rule) matchesWeekday(weekday time.Weekday) bool {
+	for _, wn := range r.ByDay {
+		if wn.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// byDayWithin applies BYDAY to the days between first and last inclusive.
+func (r *Rule) byDayWithin(first, last time.Time) []time.Time {
+	matches := make(map[time.Time]bool)
+	for _, wn := range r.ByDay {
+		var days []time.Time
+		for t := first; !t.After(last); t = t.AddDate(0, 0, 1) {
+			if t.Weekday() == wn.Weekday {
+				days = append(days, t)
+			}
+		}
+
+		switch {
+		case wn.N == 0:
+			for _, t := range days {
+				matches[t] = true
+			}
+		case wn.N > 0 && wn.N <= len(days):
+			matches[days[wn.N-1]] = true
+		case wn.N < 0 && -wn.N <= len(days):
+			matches[days[len(days)+wn.N]] = true
+		}
+	}
+
+	result := make([]time.Time, 0, len(matches))
+	for t := range matches {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
@@ -0,0 +1,113 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;interval=2;byday=mo,we", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=5", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=5"},
+		{"FREQ=YEARLY;INTERVAL=1", "FREQ=YEARLY"},
+		{"FREQ=DAILY;UNTIL=20240102", "FREQ=DAILY;UNTIL=20240102T235959Z"},
+		{" FREQ = DAILY ; UNTIL = 20240102T090000Z ", "FREQ=DAILY;UNTIL=20240102T090000Z"},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.value)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.value, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=DAILY;BYSETPOS=1",
+	}
+	for _, value := range tests {
+		if _, err := Parse(value); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q): err = %v, want ErrInvalidRule", value, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		rule    string
+		current time.Time
+		seq     int
+		want    time.Time
+		ok      bool
+	}{
+		{"FREQ=DAILY", at(2024, time.January, 30), 1, at(2024, time.January, 31), true},
+		{"FREQ=DAILY;INTERVAL=3", at(2024, time.February, 28), 1, at(2024, time.March, 2), true},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", at(2024, time.January, 1), 1, at(2024, time.January, 3), true},
+		{"FREQ=WEEKLY;BYDAY=MO,WE", at(2024, time.January, 3), 2, at(2024, time.January, 8), true},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", at(2024, time.January, 3), 2, at(2024, time.January, 15), true},
+		// Months without the day are skipped
+		{"FREQ=MONTHLY", at(2024, time.January, 31), 1, at(2024, time.March, 31), true},
+		{"FREQ=MONTHLY;BYDAY=-1FR", at(2024, time.January, 26), 1, at(2024, time.February, 23), true},
+		{"FREQ=MONTHLY;BYDAY=2TU", at(2024, time.January, 9), 1, at(2024, time.February, 13), true},
+		{"FREQ=YEARLY", at(2024, time.February, 29), 1, at(2028, time.February, 29), true},
+		{"FREQ=DAILY;COUNT=3", at(2024, time.January, 2), 2, at(2024, time.January, 3), true},
+		{"FREQ=DAILY;COUNT=3", at(2024, time.January, 3), 3, time.Time{}, false},
+		{"FREQ=DAILY;UNTIL=20240102", at(2024, time.January, 1), 1, at(2024, time.January, 2), true},
+		{"FREQ=DAILY;UNTIL=20240102", at(2024, time.January, 2), 2, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		got, ok := rule.Next(tt.current, tt.seq)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s, %d) = %s, %t, want %s, %t", tt.rule, tt.current.Format(time.DateOnly), tt.seq,
+				got.Format(time.DateOnly), ok, tt.want.Format(time.DateOnly), tt.ok)
+		}
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=TU,TH;COUNT=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The start counts as the first occurrence even off the rule
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	want := []string{"2024-01-01", "2024-01-02", "2024-01-04", "2024-01-09"}
+	got := rule.Occurrences(start, 10)
+	if len(got) != len(want) {
+		t.Fatalf("Occurrences returned %d dates, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Format(time.DateOnly) != want[i] {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i].Format(time.DateOnly), want[i])
+		}
+	}
+}
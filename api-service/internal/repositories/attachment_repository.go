@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) FindByTaskID(taskID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("task_id = ?", taskID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+// FindByTaskIDs returns the attachments of several tasks at once.
+func (r *AttachmentRepository) FindByTaskIDs(taskIDs []uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("task_id IN ?", taskIDs).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *AttachmentRepository) FindByID(id uint, taskID uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Where("id = ? AND task_id = ?", id, taskID).First(&attachment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) Create(attachment models.Attachment) (*models.Attachment, error) {
+	err := r.db.Create(&attachment).Error
+	return &attachment, err
+}
+
+func (r *AttachmentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Attachment{}, id).Error
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CalendarFeedRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarFeedRepository(db *gorm.DB) *CalendarFeedRepository {
+	return &CalendarFeedRepository{db: db}
+}
+
+func (r *CalendarFeedRepository) FindByUserID(userID uint) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.db.Where("user_id = ?", userID).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *CalendarFeedRepository) FindByToken(token string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.db.Where("token = ?", token).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// SetToken creates the user's feed or replaces the token of the existing one.
+func (r *CalendarFeedRepository) SetToken(userID uint, token string) (*models.CalendarFeed, error) {
+	feed, err := r.FindByUserID(userID)
+	if err == gorm.ErrRecordNotFound {
+		feed = &models.CalendarFeed{UserID: userID, Token: token}
+		return feed, r.db.Create(feed).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	feed.Token = token
+	return feed, r.db.Model(feed).Update("token", token).Error
+}
+
+func (r *CalendarFeedRepository) Delete(userID uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.CalendarFeed{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"time"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// FindByTaskID returns up to limit comments of a task in the order they were
+// posted, starting after the comment with ID afterID.
+func (r *CommentRepository) FindByTaskID(taskID uint, afterID uint, limit int) ([]models.TaskComment, error) {
+	var comments []models.TaskComment
+	err := r.db.Where("task_id = ? AND id > ?", taskID, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+func (r *CommentRepository) CountByTaskID(taskID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.TaskComment{}).Where("task_id = ?", taskID).Count(&count).Error
+	return count, err
+}
+
+func (r *CommentRepository) FindByID(id uint, taskID uint) (*models.TaskComment, error) {
+	var comment models.TaskComment
+	err := r.db.Where("id = ? AND task_id = ?", id, taskID).First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepository) Create(comment models.TaskComment) (*models.TaskComment, error) {
+	err := r.db.Create(&comment).Error
+	return &comment, err
+}
+
+// UpdateBody replaces the body of a comment and marks it as edited.
+func (r *CommentRepository) UpdateBody(comment *models.TaskComment, body string, editedAt time.Time) error {
+	err := r.db.Model(comment).Updates(map[string]interface{}{"body": body, "edited_at": editedAt}).Error
+	if err != nil {
+		return err
+	}
+	comment.Body = body
+	comment.EditedAt = &editedAt
+	return nil
+}
+
+func (r *CommentRepository) Delete(id uint) error {
+	return r.db.Delete(&models.TaskComment{}, id).Error
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// taskCursor points at the last task of a page. It is handed to clients as an
+// opaque base64 string and only ever compared against the keyset ordering, so
+// rows inserted between requests never shift the following pages. Sort and
+// Values carry the sort keys of that task when a custom ordering is in use.
+type taskCursor struct {
+	Sort   string `json:"s,omitempty"`
+	Values []any  `json:"v,omitempty"`
+	ID     uint   `json:"id"`
+}
+
+func encodeCursor(cur taskCursor) string {
+	data, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (taskCursor, error) {
+	var cur taskCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cur, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cur); err != nil || cur.ID == 0 {
+		return cur, ErrInvalidCursor
+	}
+	return cur, nil
+}
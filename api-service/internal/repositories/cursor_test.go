@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	value := encodeCursor(taskCursor{ID: 42})
+
+	cur, err := decodeCursor(value)
+	if err != nil {
+		t.Fatalf("decodeCursor(%q): %v", value, err)
+	}
+	if cur.ID != 42 || cur.Sort != "" || cur.Values != nil {
+		t.Errorf("decodeCursor(%q) = %+v, want a cursor at task 42", value, cur)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		// A cursor must point at a task
+		encodeCursor(taskCursor{}),
+	} {
+		if _, err := decodeCursor(value); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q): err = %v, want ErrInvalidCursor", value, err)
+		}
+	}
+}
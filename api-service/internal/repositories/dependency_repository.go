@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"errors"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDependencyCycle  = errors.New("dependency would create a cycle")
+	ErrDependencyExists = errors.New("dependency already exists")
+)
+
+type DependencyRepository struct {
+	db *gorm.DB
+}
+
+func NewDependencyRepository(db *gorm.DB) *DependencyRepository {
+	return &DependencyRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that works inside tx.
+func (r *DependencyRepository) WithTx(tx *gorm.DB) *DependencyRepository {
+	return &DependencyRepository{db: tx}
+}
+
+// Add makes taskID depend on dependsOnID, refusing edges that would close a
+// cycle in the dependency graph. Both tasks are locked and the edges walked
+// are read with locks, so that concurrent additions cannot close a cycle
+// between them; those that deadlock instead are retried.
+func (r *DependencyRepository) Add(taskID uint, dependsOnID uint) error {
+	return transaction(r.db, func(tx *gorm.DB) error {
+		if taskID == dependsOnID {
+			return ErrDependencyCycle
+		}
+
+		var locked []uint
+		err := tx.Model(&models.Task{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{taskID, dependsOnID}).Order("id").Pluck("id", &locked).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&models.TaskDependency{}).
+			Where("task_id = ? AND depends_on_id = ?", taskID, dependsOnID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDependencyExists
+		}
+
+		cycle, err := reaches(tx.Clauses(clause.Locking{Strength: "SHARE"}).Session(&gorm.Session{}), dependsOnID, taskID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrDependencyCycle
+		}
+
+		err = tx.Create(&models.TaskDependency{TaskID: taskID, DependsOnID: dependsOnID}).Error
+		if IsDuplicate(err) {
+			return ErrDependencyExists
+		}
+		return err
+	})
+}
+
+func (r *DependencyRepository) Remove(taskID uint, dependsOnID uint) error {
+	result := r.db.Where("task_id = ? AND depends_on_id = ?", taskID, dependsOnID).
+		Delete(&models.TaskDependency{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// reaches reports whether from transitively depends on to.
+func reaches(db *gorm.DB, from uint, to uint) (bool, error) {
+	return reachable(from, to, func(frontier []uint) ([]uint, error) {
+		var next []uint
+		err := db.Model(&models.TaskDependency{}).
+			Where("task_id IN ?", frontier).
+			Pluck("depends_on_id", &next).Error
+		return next, err
+	})
+}
+
+// reachable walks the dependency graph breadth first from from, asking
+// dependsOn for the tasks a frontier depends on, and reports whether it
+// arrives at to.
+func reachable(from uint, to uint, dependsOn func(frontier []uint) ([]uint, error)) (bool, error) {
+	seen := map[uint]bool{from: true}
+	frontier := []uint{from}
+	for len(frontier) > 0 {
+		next, err := dependsOn(frontier)
+		if err != nil {
+			return false, err
+		}
+
+		frontier = nil
+		for _, id := range next {
+			if id == to {
+				return true, nil
+			}
+			if !seen[id] {
+				seen[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+// FindBlockedBy maps each task to the IDs of the tasks it depends on that
+// userID can see. Tasks in the trash are left out.
+func (r *DependencyRepository) FindBlockedBy(taskIDs []uint, userID uint) (map[uint][]uint, error) {
+	var deps []models.TaskDependency
+	err := visibleTo(r.db, userID).Joins("JOIN tasks ON tasks.id = task_dependencies.depends_on_id AND tasks.deleted_at IS NULL").
+		Where("task_dependencies.task_id IN ?", taskIDs).
+		Order("task_dependencies.depends_on_id").
+		Find(&deps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint][]uint)
+	for _, dep := range deps {
+		result[dep.TaskID] = append(result[dep.TaskID], dep.DependsOnID)
+	}
+	return result, nil
+}
+
+// FindBlocking maps each task to the IDs of the tasks that depend on it that
+// userID can see. Tasks in the trash are left out.
+func (r *DependencyRepository) FindBlocking(taskIDs []uint, userID uint) (map[uint][]uint, error) {
+	var deps []models.TaskDependency
+	err := visibleTo(r.db, userID).Joins("JOIN tasks ON tasks.id = task_dependencies.task_id AND tasks.deleted_at IS NULL").
+		Where("task_dependencies.depends_on_id IN ?", taskIDs).
+		Order("task_dependencies.task_id").
+		Find(&deps).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint][]uint)
+	for _, dep := range deps {
+		result[dep.DependsOnID] = append(result[dep.DependsOnID], dep.TaskID)
+	}
+	return result, nil
+}
+
+// FindBlockerTasks returns the tasks taskID depends on that userID can see.
+func (r *DependencyRepository) FindBlockerTasks(taskID uint, userID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	err := visibleTo(r.db, userID).Joins("JOIN task_dependencies ON task_dependencies.depends_on_id = tasks.id").
+		Where("task_dependencies.task_id = ?", taskID).
+		Order("tasks.id").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindBlockingTasks returns the tasks depending on taskID that userID can see.
+func (r *DependencyRepository) FindBlockingTasks(taskID uint, userID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	err := visibleTo(r.db, userID).Joins("JOIN task_dependencies ON task_dependencies.task_id = tasks.id").
+		Where("task_dependencies.depends_on_id = ?", taskID).
+		Order("tasks.id").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindUnfinishedBlockers returns the IDs of the tasks taskID still waits on.
+func (r *DependencyRepository) FindUnfinishedBlockers(taskID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.Task{}).
+		Joins("JOIN task_dependencies ON task_dependencies.depends_on_id = tasks.id").
+		Where("task_dependencies.task_id = ? AND tasks.status_category <> ?", taskID, models.CategoryDone).
+		Order("tasks.id").
+		Pluck("tasks.id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+)
+
+// graph maps each task to the tasks it depends on and counts the lookups
+// reachable makes, one per frontier.
+type graph struct {
+	edges   map[uint][]uint
+	lookups int
+}
+
+func (g *graph) dependsOn(frontier []uint) ([]uint, error) {
+	g.lookups++
+	var next []uint
+	for _, id := range frontier {
+		next = append(next, g.edges[id]...)
+	}
+	return next, nil
+}
+
+func TestReachableFollowsChains(t *testing.T) {
+	g := &graph{edges: map[uint][]uint{1: {2}, 2: {3}, 3: {4}}}
+
+	if ok, _ := reachable(1, 4, g.dependsOn); !ok {
+		t.Error("1 → 2 → 3 → 4: 4 not reached from 1")
+	}
+	if ok, _ := reachable(4, 1, g.dependsOn); ok {
+		t.Error("1 → 2 → 3 → 4: 1 reached from 4 against the edges")
+	}
+}
+
+// Adding 3 → 1 to this graph would close a cycle, which is what Add checks
+// by asking whether 1 already reaches 3.
+func TestReachableDetectsCycleToBe(t *testing.T) {
+	g := &graph{edges: map[uint][]uint{1: {2, 5}, 2: {3}, 5: {6}}}
+
+	if ok, _ := reachable(1, 3, g.dependsOn); !ok {
+		t.Error("3 not reached from 1 through 2")
+	}
+	if ok, _ := reachable(5, 3, g.dependsOn); ok {
+		t.Error("3 reached from 5, which only leads to 6")
+	}
+}
+
+func TestReachableStopsOnExistingCycles(t *testing.T) {
+	g := &graph{edges: map[uint][]uint{1: {2}, 2: {3}, 3: {1}}}
+
+	if ok, err := reachable(1, 9, g.dependsOn); ok || err != nil {
+		t.Fatalf("reachable(1, 9) = %t, %v, want false", ok, err)
+	}
+	// Every task is visited once: the frontiers {1}, {2} and {3}
+	if g.lookups != 3 {
+		t.Errorf("%d lookups walking a cycle of three, want 3", g.lookups)
+	}
+}
+
+func TestReachablePassesErrorsOn(t *testing.T) {
+	failure := errors.New("lost connection")
+	_, err := reachable(1, 2, func([]uint) ([]uint, error) { return nil, failure })
+	if !errors.Is(err, failure) {
+		t.Errorf("reachable: err = %v, want %v", err, failure)
+	}
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type HistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewHistoryRepository(db *gorm.DB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that works inside tx.
+func (r *HistoryRepository) WithTx(tx *gorm.DB) *HistoryRepository {
+	return &HistoryRepository{db: tx}
+}
+
+// Append adds entries to the history. Entries are never changed afterwards.
+func (r *HistoryRepository) Append(entries []models.TaskHistory) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Create(&entries).Error
+}
+
+// FindByTaskID returns up to limit entries of a task's history, newest first.
+// A non-zero before only returns entries older than the entry with that ID.
+func (r *HistoryRepository) FindByTaskID(taskID uint, before uint, limit int) ([]models.TaskHistory, error) {
+	query := r.db.Where("task_id = ?", taskID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var entries []models.TaskHistory
+	err := query.Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
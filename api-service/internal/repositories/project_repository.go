@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"errors"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastOwner is returned for membership changes that would leave a project
+// without an owner.
+var ErrLastOwner = errors.New("project needs an owner")
+
+type ProjectRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectRepository(db *gorm.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that works inside tx.
+func (r *ProjectRepository) WithTx(tx *gorm.DB) *ProjectRepository {
+	return &ProjectRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction, see
+// TaskRepository.Transaction.
+func (r *ProjectRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return transaction(r.db, fn)
+}
+
+// FindByUserID returns the projects the user is a member of in creation
+// order, with the user's role in each. Archived projects are left out unless
+// archived is set, in which case only those are returned.
+func (r *ProjectRepository) FindByUserID(userID uint, archived bool) ([]models.Project, error) {
+	var projects []models.Project
+	query := r.asMember(userID)
+	if archived {
+		query = query.Where("projects.archived_at IS NOT NULL")
+	} else {
+		query = query.Where("projects.archived_at IS NULL")
+	}
+	if err := query.Order("projects.id").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, r.withCounts(projects)
+}
+
+// FindByID returns a project the user is a member of, with the user's role.
+func (r *ProjectRepository) FindByID(id uint, userID uint) (*models.Project, error) {
+	var project models.Project
+	err := r.asMember(userID).Where("projects.id = ?", id).First(&project).Error
+	if err != nil {
+		return nil, err
+	}
+	projects := []models.Project{project}
+	if err := r.withCounts(projects); err != nil {
+		return nil, err
+	}
+	return &projects[0], nil
+}
+
+// asMember selects the projects userID is a member of, along with their role.
+func (r *ProjectRepository) asMember(userID uint) *gorm.DB {
+	return r.db.Select("projects.*, project_members.role").
+		Joins("JOIN project_members ON project_members.project_id = projects.id AND project_members.user_id = ?", userID)
+}
+
+// FindRole returns the role userID holds in a project, or
+// gorm.ErrRecordNotFound when they are not a member.
+func (r *ProjectRepository) FindRole(projectID uint, userID uint) (models.ProjectRole, error) {
+	member, err := r.FindMember(projectID, userID)
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Create stores a new project with its creator as the owner.
+func (r *ProjectRepository) Create(project models.Project) (*models.Project, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ProjectMember{ProjectID: project.ID, UserID: project.UserID, Role: models.RoleOwner}).Error
+	})
+	project.Role = models.RoleOwner
+	return &project, err
+}
+
+func (r *ProjectRepository) Update(project models.Project) (*models.Project, error) {
+	err := r.db.Save(&project).Error
+	return &project, err
+}
+
+// Delete removes a project and returns the IDs of the tasks it held. Those
+// tasks are kept and no longer belong to any project; trashed tasks are let
+// go of by the foreign key. All of them follow the default workflow from
+// then on.
+func (r *ProjectRepository) Delete(id uint) ([]uint, error) {
+	var released []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var held []uint
+		err := tx.Unscoped().Model(&models.Task{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("project_id = ?", id).Pluck("id", &held).Error
+		if err != nil {
+			return err
+		}
+		if len(held) > 0 {
+			if err := fitWorkflow(tx.Unscoped(), held, models.DefaultWorkflow()); err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&models.Task{}).Where("project_id = ?", id).Pluck("id", &released).Error
+		if err != nil {
+			return err
+		}
+		if len(released) > 0 {
+			err = tx.Model(&models.Task{}).Where("id IN ?", released).
+				Updates(map[string]interface{}{"project_id": nil, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Project{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+// FindMembers returns the members of a project, owners first.
+func (r *ProjectRepository) FindMembers(projectID uint) ([]models.ProjectMember, error) {
+	var members []models.ProjectMember
+	err := r.members().Where("project_members.project_id = ?", projectID).
+		Order("FIELD(project_members.role, 'owner', 'editor', 'viewer'), users.username").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *ProjectRepository) FindMember(projectID uint, userID uint) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	err := r.members().Where("project_members.project_id = ? AND project_members.user_id = ?", projectID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *ProjectRepository) members() *gorm.DB {
+	return r.db.Model(&models.ProjectMember{}).Select("project_members.*, users.username").
+		Joins("JOIN users ON users.id = project_members.user_id AND users.deleted_at IS NULL")
+}
+
+func (r *ProjectRepository) AddMember(member models.ProjectMember) (*models.ProjectMember, error) {
+	err := r.db.Create(&member).Error
+	return &member, err
+}
+
+// SetMemberRole changes the role of a member. Demoting the last owner fails
+// with ErrLastOwner.
+func (r *ProjectRepository) SetMemberRole(projectID uint, userID uint, role models.ProjectRole) error {
+	return r.changeMembers(projectID, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&models.ProjectMember{}).Where("project_id = ? AND user_id = ?", projectID, userID).Update("role", role)
+	})
+}
+
+// RemoveMember takes a user out of a project. Removing the last owner fails
+// with ErrLastOwner.
+func (r *ProjectRepository) RemoveMember(projectID uint, userID uint) error {
+	return r.changeMembers(projectID, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&models.ProjectMember{})
+	})
+}
+
+// changeMembers applies change to the members of a project, making sure the
+// project keeps an owner. Changes to one project are serialized by locking
+// its row.
+func (r *ProjectRepository) changeMembers(projectID uint, change func(tx *gorm.DB) *gorm.DB) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var project models.Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, projectID).Error; err != nil {
+			return err
+		}
+
+		result := change(tx)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var owners int64
+		err := tx.Model(&models.ProjectMember{}).Where("project_id = ? AND role = ?", projectID, models.RoleOwner).
+			Count(&owners).Error
+		if err != nil {
+			return err
+		}
+		if owners == 0 {
+			return ErrLastOwner
+		}
+		return nil
+	})
+}
+
+// withCounts fills in how many live tasks each project holds and how many of
+// them are completed.
+func (r *ProjectRepository) withCounts(projects []models.Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+
+	var rows []struct {
+		ProjectID uint
+		Total     int64
+		Completed int64
+	}
+	err := r.db.Model(&models.Task{}).
+		Select("project_id, COUNT(*) AS total, SUM(CASE WHEN status_category = ? THEN 1 ELSE 0 END) AS completed", models.CategoryDone).
+		Where("project_id IN ?", ids).
+		Group("project_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	index := make(map[uint]int, len(projects))
+	for i, project := range projects {
+		index[project.ID] = i
+	}
+	for _, row := range rows {
+		projects[index[row.ProjectID]].TaskCount = row.Total
+		projects[index[row.ProjectID]].CompletedCount = row.Completed
+	}
+	return nil
+}
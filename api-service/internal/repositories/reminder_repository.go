@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrLeaseLost is returned when a reminder is marked by a replica whose
+// claim on it ran out and was taken over by another one.
+var ErrLeaseLost = errors.New("reminder lease lost")
+
+type ReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that works inside tx.
+func (r *ReminderRepository) WithTx(tx *gorm.DB) *ReminderRepository {
+	return &ReminderRepository{db: tx}
+}
+
+// FindByTaskID returns the reminders the user set on a task. Reminders are
+// personal, members of a shared project only see their own.
+func (r *ReminderRepository) FindByTaskID(taskID uint, userID uint) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.db.Where("task_id = ? AND user_id = ?", taskID, userID).Order("remind_at").Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *ReminderRepository) Create(reminder models.Reminder) (*models.Reminder, error) {
+	err := r.db.Create(&reminder).Error
+	return &reminder, err
+}
+
+func (r *ReminderRepository) Delete(id uint, taskID uint, userID uint) error {
+	result := r.db.Where("id = ? AND task_id = ? AND user_id = ?", id, taskID, userID).Delete(&models.Reminder{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Reschedule moves the pending offset reminders of a task along with its due date.
+func (r *ReminderRepository) Reschedule(taskID uint, dueDate *time.Time) error {
+	var reminders []models.Reminder
+	err := r.db.Where("task_id = ? AND offset_minutes IS NOT NULL AND fired_at IS NULL", taskID).
+		Find(&reminders).Error
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		reminder.Schedule(dueDate)
+		err := r.db.Model(&models.Reminder{}).Where("id = ?", reminder.ID).
+			Update("remind_at", reminder.RemindAt).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyOffsets gives a task the offset reminders of another one, scheduled
+// against the new task's due date.
+func (r *ReminderRepository) CopyOffsets(fromTaskID uint, to *models.Task) error {
+	var reminders []models.Reminder
+	err := r.db.Where("task_id = ? AND offset_minutes IS NOT NULL", fromTaskID).Find(&reminders).Error
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		copied := models.Reminder{
+			TaskID:        to.ID,
+			UserID:        reminder.UserID,
+			OffsetMinutes: reminder.OffsetMinutes,
+			Channel:       reminder.Channel,
+			Target:        reminder.Target,
+		}
+		copied.Schedule(to.DueDate)
+		if err := r.db.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimNext leases the reminder that has been due longest to owner and
+// returns it, or nil when none is due. Claims are taken with a conditional
+// update so that only one replica ever wins a given reminder; a lease that
+// runs out before the reminder is marked fired (a crashed replica) makes it
+// claimable again. Reminders are claimed one at a time, right before they
+// are delivered, so that a slow delivery cannot eat into the lease of the
+// next one.
+// Reminders of tasks in the trash are skipped until the task is restored.
+func (r *ReminderRepository) ClaimNext(owner string, now time.Time, lease time.Duration) (*models.Reminder, error) {
+	until := now.Add(lease)
+	for {
+		var reminder models.Reminder
+		err := r.db.Joins("JOIN tasks ON tasks.id = reminders.task_id AND tasks.deleted_at IS NULL").
+			Where("reminders.fired_at IS NULL AND reminders.remind_at <= ? AND (reminders.claimed_until IS NULL OR reminders.claimed_until < ?)", now, now).
+			Order("reminders.remind_at").
+			First(&reminder).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Another replica may have claimed it since; then move on to the next one
+		result := r.db.Model(&models.Reminder{}).
+			Where("id = ? AND fired_at IS NULL AND (claimed_until IS NULL OR claimed_until < ?)", reminder.ID, now).
+			Updates(map[string]interface{}{"claimed_by": owner, "claimed_until": until})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			reminder.ClaimedBy = owner
+			reminder.ClaimedUntil = &until
+			return &reminder, nil
+		}
+	}
+}
+
+// MarkFired closes a reminder for good. lastError is empty when the final
+// attempt was delivered and holds the reason when delivery was given up.
+// ErrLeaseLost is returned when owner no longer holds the reminder.
+func (r *ReminderRepository) MarkFired(id uint, owner string, firedAt time.Time, lastError string) error {
+	result := r.db.Model(&models.Reminder{}).
+		Where("id = ? AND claimed_by = ? AND fired_at IS NULL", id, owner).
+		Updates(map[string]interface{}{
+			"fired_at":   firedAt,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": lastError,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery and keeps the reminder leased until
+// retryAt, after which another attempt may claim it. Like MarkFired it
+// returns ErrLeaseLost when owner no longer holds the reminder.
+func (r *ReminderRepository) MarkFailed(id uint, owner string, deliveryErr error, retryAt time.Time) error {
+	result := r.db.Model(&models.Reminder{}).
+		Where("id = ? AND claimed_by = ? AND fired_at IS NULL", id, owner).
+		Updates(map[string]interface{}{
+			"attempts":      gorm.Expr("attempts + 1"),
+			"last_error":    deliveryErr.Error(),
+			"claimed_until": retryAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"taskmango/apisvc/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TagRepository struct {
@@ -14,6 +15,17 @@ func NewTagRepository(db *gorm.DB) *TagRepository {
 	return &TagRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that works inside tx.
+func (r *TagRepository) WithTx(tx *gorm.DB) *TagRepository {
+	return &TagRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction, see
+// TaskRepository.Transaction.
+func (r *TagRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return transaction(r.db, fn)
+}
+
 func (r *TagRepository) FindByTaskID(taskID uint) ([]models.Tag, error) {
 	var tags []models.Tag
 	err := r.db.Joins("JOIN task_tags ON task_tags.tag_id = tags.id").
@@ -22,28 +34,172 @@ func (r *TagRepository) FindByTaskID(taskID uint) ([]models.Tag, error) {
 	return tags, err
 }
 
+// FindByUserID returns the user's tags by name.
 func (r *TagRepository) FindByUserID(userID uint) ([]models.Tag, error) {
 	var tags []models.Tag
-	err := r.db.Distinct("tags.id, tags.name, tags.created_at").
-		Joins("JOIN task_tags ON task_tags.tag_id = tags.id").
-		Joins("JOIN tasks ON tasks.id = task_tags.task_id").
-		Where("tasks.user_id = ?", userID).
-		Order("tags.name").
-		Find(&tags).Error
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
 	return tags, err
 }
 
-func (r *TagRepository) FindOrCreateByName(name string) (*models.Tag, error) {
+// FindTaskIDs returns, for each of the user's tags, the live tasks carrying
+// it that the user can see.
+func (r *TagRepository) FindTaskIDs(userID uint) (map[uint][]uint, error) {
+	var rows []struct {
+		TagID  uint
+		TaskID uint
+	}
+	query := r.db.Table("task_tags").Select("task_tags.tag_id, task_tags.task_id").
+		Joins("JOIN tags ON tags.id = task_tags.tag_id").
+		Joins("JOIN tasks ON tasks.id = task_tags.task_id AND tasks.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID)
+	if err := visibleTo(query, userID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	taskIDs := make(map[uint][]uint)
+	for _, row := range rows {
+		taskIDs[row.TagID] = append(taskIDs[row.TagID], row.TaskID)
+	}
+	return taskIDs, nil
+}
+
+func (r *TagRepository) FindByID(id uint, userID uint) (*models.Tag, error) {
 	var tag models.Tag
-	err := r.db.Where("name = ?", name).First(&tag).Error
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindByName returns the user's tag with the given name, for checking that a
+// name is free before a tag takes it.
+func (r *TagRepository) FindByName(userID uint, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindOrCreateByName returns the user's tag with the given name, creating it
+// if needed, and reports whether it was created. Losing a race to create the
+// tag fails the transaction in a way that makes it run again.
+func (r *TagRepository) FindOrCreateByName(userID uint, name string) (*models.Tag, bool, error) {
+	var tag models.Tag
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
 	if err == gorm.ErrRecordNotFound {
+		tag.UserID = userID
 		tag.Name = name
-		err = r.db.Create(&tag).Error
-		if err != nil {
-			return nil, err
+		if err := r.db.Create(&tag).Error; err != nil {
+			return nil, false, lostCreateRace(err)
 		}
+		return &tag, true, nil
 	} else if err != nil {
+		return nil, false, err
+	}
+	return &tag, false, nil
+}
+
+func (r *TagRepository) Create(tag models.Tag) (*models.Tag, error) {
+	err := r.db.Create(&tag).Error
+	return &tag, err
+}
+
+// Update stores a changed tag and returns the IDs of the tasks carrying it,
+// whose versions are bumped since the tag is part of them.
+func (r *TagRepository) Update(tag models.Tag) ([]uint, error) {
+	var taskIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&tag).Error; err != nil {
+			return err
+		}
+		var err error
+		taskIDs, err = touchTagged(tx, tag.ID)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
-	return &tag, nil
-}
\ No newline at end of file
+	return taskIDs, nil
+}
+
+// Delete removes a tag from every task carrying it and then the tag itself,
+// returning the IDs of those tasks.
+func (r *TagRepository) Delete(id uint) ([]uint, error) {
+	var taskIDs []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if taskIDs, err = touchTagged(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return taskIDs, nil
+}
+
+// Merge folds the source tag into the target: every task carrying the source
+// carries the target instead, and the source is deleted. It returns the IDs
+// of the tasks that carried the source and, of those, the ones that did not
+// carry the target already.
+func (r *TagRepository) Merge(sourceID uint, targetID uint) ([]uint, []uint, error) {
+	var taskIDs, added []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var tags []models.Tag
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", []uint{sourceID, targetID}).Find(&tags).Error
+		if err != nil {
+			return err
+		}
+		if len(tags) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err = tx.Table("task_tags").Where("tag_id = ?", sourceID).
+			Where("task_id NOT IN (SELECT task_id FROM task_tags WHERE tag_id = ?)", targetID).
+			Pluck("task_id", &added).Error
+		if err != nil {
+			return err
+		}
+		if taskIDs, err = touchTagged(tx, sourceID); err != nil {
+			return err
+		}
+
+		if len(added) > 0 {
+			err = tx.Exec("UPDATE task_tags SET tag_id = ? WHERE tag_id = ? AND task_id IN ?", targetID, sourceID, added).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec("DELETE FROM task_tags WHERE tag_id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, sourceID).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return taskIDs, added, nil
+}
+
+// touchTagged bumps the version of every task carrying a tag, trashed ones
+// included, and returns their IDs.
+func touchTagged(tx *gorm.DB, tagID uint) ([]uint, error) {
+	var taskIDs []uint
+	err := tx.Table("task_tags").Where("tag_id = ?", tagID).Order("task_id").Pluck("task_id", &taskIDs).Error
+	if err != nil || len(taskIDs) == 0 {
+		return nil, err
+	}
+	err = tx.Unscoped().Model(&models.Task{}).Where("id IN ?", taskIDs).
+		Update("version", gorm.Expr("version + 1")).Error
+	if err != nil {
+		return nil, err
+	}
+	return taskIDs, nil
+}
@@ -1,11 +1,21 @@
 package repositories
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/search"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict is returned when a task was changed by someone else
+// since the version being written was read.
+var ErrVersionConflict = errors.New("task version conflict")
+
 type TaskRepository struct {
 	db *gorm.DB
 }
@@ -14,62 +24,607 @@ func NewTaskRepository(db *gorm.DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
-func (r *TaskRepository) FindByUserID(userID uint, filter models.TaskFilter) ([]models.Task, error) {
+// WithTx returns a copy of the repository that works inside tx.
+func (r *TaskRepository) WithTx(tx *gorm.DB) *TaskRepository {
+	return &TaskRepository{db: tx}
+}
+
+// Transaction runs fn inside a database transaction, committing when it
+// returns nil. Repositories bound to tx with WithTx take part in it. When the
+// transaction deadlocks or races another one to create a row, fn is run again
+// in a new transaction, so it must start over from scratch each time.
+func (r *TaskRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return transaction(r.db, fn)
+}
+
+func (r *TaskRepository) FindByUserID(userID uint, filter models.TaskFilter, page models.Pagination) ([]models.Task, string, error) {
+	var tasks []models.Task
+
+	var relevance *sortField
+	if filter.Query != "" {
+		relevance = r.relevanceField(filter.Query)
+	}
+
+	sort, err := parseSort(page.Sort, relevance)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := r.filtered(userID, filter)
+
+	if page.Cursor != "" {
+		cur, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if query, err = sort.after(query, cur); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	err = sort.apply(query).Limit(page.Limit + 1).Preload("Tags").Preload("Assignees").Find(&tasks).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	hasMore := len(tasks) > page.Limit
+	if hasMore {
+		tasks = tasks[:page.Limit]
+	}
+
+	if relevance != nil {
+		if err := r.loadRelevance(tasks, relevance); err != nil {
+			return nil, "", err
+		}
+	}
+
+	nextCursor := ""
+	if hasMore {
+		nextCursor = encodeCursor(sort.cursorFor(&tasks[len(tasks)-1]))
+	}
+
+	return tasks, nextCursor, nil
+}
+
+func (r *TaskRepository) CountByUserID(userID uint, filter models.TaskFilter) (int64, error) {
+	var count int64
+	err := r.filtered(userID, filter).Model(&models.Task{}).Count(&count).Error
+	return count, err
+}
+
+// FindWithDueDate returns the user's tasks that have a due date, soonest
+// first, for the calendar feed.
+func (r *TaskRepository) FindWithDueDate(userID uint, filter models.TaskFilter) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.filtered(userID, filter).
+		Where("tasks.due_date IS NOT NULL").
+		Order("tasks.due_date, tasks.id").
+		Preload("Tags").Preload("Assignees").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// FindMatching returns up to limit of the user's tasks that match filter, in
+// ID order.
+func (r *TaskRepository) FindMatching(userID uint, filter models.TaskFilter, limit int) ([]models.Task, error) {
 	var tasks []models.Task
+	err := r.filtered(userID, filter).Order("tasks.id").Limit(limit).Preload("Tags").Preload("Assignees").Find(&tasks).Error
+	return tasks, err
+}
 
-	query := r.db.Where("user_id = ?", userID)
+func (r *TaskRepository) filtered(userID uint, filter models.TaskFilter) *gorm.DB {
+	query := visibleTo(r.db, userID)
 
 	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
+		query = query.Where("tasks.status = ?", filter.Status)
+	}
+	if filter.Category != "" {
+		query = query.Where("tasks.status_category = ?", filter.Category)
 	}
 	if filter.Priority != "" {
-		query = query.Where("priority = ?", filter.Priority)
+		query = query.Where("tasks.priority = ?", filter.Priority)
 	}
 	if filter.DueDateBefore != "" {
-		query = query.Where("due_date <= ?", filter.DueDateBefore)
+		query = query.Where("tasks.due_date <= ?", filter.DueDateBefore)
 	}
 	if filter.DueDateAfter != "" {
-		query = query.Where("due_date >= ?", filter.DueDateAfter)
+		query = query.Where("tasks.due_date >= ?", filter.DueDateAfter)
 	}
+	// A tag matches the tags below it as well
 	if filter.TagName != "" {
-		query = query.Joins("JOIN task_tags ON task_tags.task_id = tasks.id").
-			Joins("JOIN tags ON tags.id = task_tags.tag_id").
-			Where("tags.name = ?", filter.TagName)
+		query = query.Where("tasks.id IN (SELECT task_tags.task_id FROM task_tags JOIN tags ON tags.id = task_tags.tag_id "+
+			"WHERE tags.user_id = ? AND (tags.name = ? OR tags.name LIKE ? ESCAPE '!'))",
+			userID, filter.TagName, escapeLike(filter.TagName+models.TagSeparator)+"%")
+	}
+	if filter.Assignee == "me" {
+		query = query.Where("tasks.id IN (SELECT task_id FROM task_assignees WHERE user_id = ?)", userID)
+	} else if filter.Assignee == "none" {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM task_assignees WHERE task_assignees.task_id = tasks.id)")
+	}
+	if filter.ParentID == "root" {
+		query = query.Where("tasks.parent_id IS NULL")
+	} else if filter.ParentID != "" {
+		query = query.Where("tasks.parent_id = ?", filter.ParentID)
+	}
+	// Tasks of archived projects only show up when their project is asked for
+	if filter.ProjectID == "none" {
+		query = query.Where("tasks.project_id IS NULL")
+	} else if filter.ProjectID != "" {
+		query = query.Where("tasks.project_id = ?", filter.ProjectID)
+	} else {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM projects WHERE projects.id = tasks.project_id AND projects.archived_at IS NOT NULL)")
+	}
+	if filter.Query != "" {
+		query = r.search(query, filter.Query)
 	}
 
-	err := query.Preload("Tags").Find(&tasks).Error
-	return tasks, err
+	return query
+}
+
+func (r *TaskRepository) isMySQL() bool {
+	return r.db.Dialector.Name() == "mysql"
+}
+
+// search narrows the query to tasks matching q. MySQL uses the FULLTEXT index
+// on title and description, other backends require every term to appear in
+// one of the two columns.
+func (r *TaskRepository) search(query *gorm.DB, q string) *gorm.DB {
+	if r.isMySQL() {
+		return query.Where("MATCH(tasks.title, tasks.description) AGAINST (? IN NATURAL LANGUAGE MODE)", q)
+	}
+
+	for _, term := range search.Terms(q) {
+		pattern := likePattern(term)
+		query = query.Where("(tasks.title LIKE ? ESCAPE '!' OR tasks.description LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	return query
+}
+
+// relevanceField scores a task against q, weighing title matches above
+// description matches when the LIKE fallback is in use.
+func (r *TaskRepository) relevanceField(q string) *sortField {
+	field := &sortField{
+		kind:  sortFloat,
+		value: func(task *models.Task) any { return task.Relevance },
+	}
+
+	if r.isMySQL() {
+		field.expr = "MATCH(tasks.title, tasks.description) AGAINST (? IN NATURAL LANGUAGE MODE)"
+		field.args = []any{q}
+		return field
+	}
+
+	var terms []string
+	for _, term := range search.Terms(q) {
+		pattern := likePattern(term)
+		terms = append(terms, "CASE WHEN tasks.title LIKE ? ESCAPE '!' THEN 2 ELSE 0 END",
+			"CASE WHEN tasks.description LIKE ? ESCAPE '!' THEN 1 ELSE 0 END")
+		field.args = append(field.args, pattern, pattern)
+	}
+	if len(terms) == 0 {
+		field.expr = "0"
+	} else {
+		field.expr = "(" + strings.Join(terms, " + ") + ")"
+	}
+	return field
+}
+
+func (r *TaskRepository) loadRelevance(tasks []models.Task, relevance *sortField) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	var rows []struct {
+		ID        uint
+		Relevance float64
+	}
+	err := r.db.Model(&models.Task{}).
+		Select("tasks.id, "+relevance.expr+" AS relevance", relevance.args...).
+		Where("tasks.id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	scores := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		scores[row.ID] = row.Relevance
+	}
+	for i := range tasks {
+		tasks[i].Relevance = scores[tasks[i].ID]
+	}
+	return nil
+}
+
+func likePattern(term string) string {
+	return "%" + escapeLike(term) + "%"
+}
+
+// escapeLike makes text match itself literally in a LIKE pattern using
+// ESCAPE '!'.
+func escapeLike(text string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(text)
 }
 
 func (r *TaskRepository) FindByID(id uint, userID uint) (*models.Task, error) {
 	var task models.Task
-	err := r.db.Where("id = ? AND user_id = ?", id, userID).Preload("Tags").First(&task).Error
+	err := visibleTo(r.db, userID).Where("tasks.id = ?", id).Preload("Tags").Preload("Assignees").First(&task).Error
 	return &task, err
 }
 
+// visibleTo narrows query to the tasks userID may see: their own tasks
+// outside any project and every task of the projects they are a member of.
+func visibleTo(query *gorm.DB, userID uint) *gorm.DB {
+	return query.Where("((tasks.project_id IS NULL AND tasks.user_id = ?) OR tasks.project_id IN (SELECT project_id FROM project_members WHERE user_id = ?))", userID, userID)
+}
+
+// Create stores a new task. Tags and assignees are linked separately, by
+// AddTag and SetAssignees.
 func (r *TaskRepository) Create(task models.Task) (*models.Task, error) {
-	err := r.db.Create(&task).Error
+	err := r.db.Omit(clause.Associations).Create(&task).Error
 	return &task, err
 }
 
+// Update stores task only if the row still has the version the task was
+// read at, bumping the version. Otherwise ErrVersionConflict is returned.
 func (r *TaskRepository) Update(task models.Task) (*models.Task, error) {
-	err := r.db.Save(&task).Error
-	return &task, err
+	version := task.Version
+	task.Version++
+	result := r.db.Model(&task).Where("version = ?", version).
+		Select("*").Omit("created_at", clause.Associations).Updates(&task)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrVersionConflict
+	}
+	return &task, nil
 }
 
-func (r *TaskRepository) Delete(id uint) error {
-	// Delete task_tags associations first
-	if err := r.db.Exec("DELETE FROM task_tags WHERE task_id = ?", id).Error; err != nil {
-		return err
+// Delete moves a task to the trash if it is still at the given version. With
+// cascade its whole subtree goes with it, otherwise its direct subtasks are
+// moved up to the task's own parent. Tag links are kept so that a restored
+// task gets its tags back.
+func (r *TaskRepository) Delete(id uint, version uint, cascade bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, id).Error; err != nil {
+			return err
+		}
+		if task.Version != version {
+			return ErrVersionConflict
+		}
+
+		ids := []uint{id}
+		if cascade {
+			descendants, err := findDescendants(tx, []uint{id})
+			if err != nil {
+				return err
+			}
+			for _, descendant := range descendants {
+				ids = append(ids, descendant.ID)
+			}
+		} else {
+			err := tx.Model(&models.Task{}).Where("parent_id = ?", id).
+				Updates(map[string]interface{}{"parent_id": task.ParentID, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// One statement, so the subtree shares a deletion time to be restored by
+		return tx.Delete(&models.Task{}, ids).Error
+	})
+}
+
+// FindTrash returns the deleted tasks the user can see, most recently
+// deleted first.
+func (r *TaskRepository) FindTrash(userID uint, limit int) ([]models.Task, int64, error) {
+	query := visibleTo(r.db.Unscoped().Model(&models.Task{}), userID).Where("tasks.deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var tasks []models.Task
+	err := query.Order("deleted_at DESC, id DESC").Limit(limit).Preload("Tags").Preload("Assignees").Find(&tasks).Error
+	return tasks, total, err
+}
+
+// FindTrashedByID returns a deleted task the user can see.
+func (r *TaskRepository) FindTrashedByID(id uint, userID uint) (*models.Task, error) {
+	var task models.Task
+	err := visibleTo(r.db.Unscoped(), userID).Where("tasks.id = ? AND tasks.deleted_at IS NOT NULL", id).
+		First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Restore takes a task out of the trash together with the subtasks that were
+// deleted along with it. A task whose parent is not restored with it, and is
+// not live either, becomes a top-level task.
+func (r *TaskRepository) Restore(id uint, userID uint) ([]models.Task, error) {
+	var restored []models.Task
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		err := visibleTo(tx.Unscoped(), userID).Where("tasks.id = ? AND tasks.deleted_at IS NOT NULL", id).
+			First(&task).Error
+		if err != nil {
+			return err
+		}
+
+		trashedTogether := tx.Unscoped().Where("deleted_at = ?", task.DeletedAt.Time).Session(&gorm.Session{})
+		descendants, err := findDescendants(trashedTogether, []uint{id})
+		if err != nil {
+			return err
+		}
+		ids := []uint{id}
+		for _, descendant := range descendants {
+			ids = append(ids, descendant.ID)
+		}
+
+		if task.ParentID != nil {
+			var count int64
+			if err := tx.Model(&models.Task{}).Where("id = ?", *task.ParentID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				if err := tx.Unscoped().Model(&task).Update("parent_id", nil).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		err = tx.Unscoped().Model(&models.Task{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Preload("Tags").Preload("Assignees").Order("id").Find(&restored).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-	// Then delete the task
-	return r.db.Delete(&models.Task{}, id).Error
+	return restored, nil
+}
+
+// FindPurgeable returns up to limit IDs of tasks that were moved to the
+// trash before cutoff.
+func (r *TaskRepository) FindPurgeable(cutoff time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Unscoped().Model(&models.Task{}).Where("deleted_at < ?", cutoff).
+		Order("id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// Purge permanently deletes those of the given tasks that are still in the
+// trash and returns their IDs.
+func (r *TaskRepository) Purge(ids []uint) ([]uint, error) {
+	var purged []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Tasks restored in the meantime are left alone
+		err := tx.Unscoped().Model(&models.Task{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND deleted_at IS NOT NULL", ids).Pluck("id", &purged).Error
+		if err != nil || len(purged) == 0 {
+			return err
+		}
+
+		// Delete task_tags associations first
+		if err := tx.Exec("DELETE FROM task_tags WHERE task_id IN ?", purged).Error; err != nil {
+			return err
+		}
+		// Then delete the tasks
+		return tx.Unscoped().Delete(&models.Task{}, purged).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// FindDescendants returns every task below the given roots that the user
+// can see, level by level.
+func (r *TaskRepository) FindDescendants(rootIDs []uint, userID uint) ([]models.Task, error) {
+	return findDescendants(visibleTo(r.db, userID).Session(&gorm.Session{}), rootIDs)
+}
+
+func findDescendants(db *gorm.DB, rootIDs []uint) ([]models.Task, error) {
+	var descendants []models.Task
+	seen := make(map[uint]bool)
+	for _, id := range rootIDs {
+		seen[id] = true
+	}
+	parentIDs := rootIDs
+	for len(parentIDs) > 0 {
+		var children []models.Task
+		if err := db.Where("parent_id IN ?", parentIDs).Preload("Tags").Preload("Assignees").Find(&children).Error; err != nil {
+			return nil, err
+		}
+
+		parentIDs = nil
+		for _, child := range children {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			descendants = append(descendants, child)
+			parentIDs = append(parentIDs, child.ID)
+		}
+	}
+	return descendants, nil
+}
+
+// IsDescendant reports whether taskID lies somewhere below ancestorID.
+func (r *TaskRepository) IsDescendant(taskID uint, ancestorID uint) (bool, error) {
+	seen := make(map[uint]bool)
+	for current := taskID; !seen[current]; {
+		seen[current] = true
+
+		var task models.Task
+		if err := r.db.Select("id", "parent_id").First(&task, current).Error; err != nil {
+			return false, err
+		}
+		if task.ParentID == nil {
+			return false, nil
+		}
+		if *task.ParentID == ancestorID {
+			return true, nil
+		}
+		current = *task.ParentID
+	}
+	return false, nil
+}
+
+// Move puts a task under parentID, or at the top level when it is nil, only
+// if the row still has the given version. Otherwise ErrVersionConflict is
+// returned.
+func (r *TaskRepository) Move(id uint, version uint, parentID *uint) error {
+	result := r.db.Model(&models.Task{}).Where("id = ? AND version = ?", id, version).
+		Updates(map[string]interface{}{"parent_id": parentID, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// SetNextOccurrence records nextID as the occurrence spawned when id was
+// completed. The task's version is left alone, it is bookkeeping for the
+// series rather than a change to the task.
+func (r *TaskRepository) SetNextOccurrence(id uint, nextID uint) error {
+	return r.db.Model(&models.Task{}).Where("id = ?", id).UpdateColumn("next_occurrence_id", nextID).Error
+}
+
+// SetProject moves the given tasks into a project, or out of every project
+// when projectID is nil.
+func (r *TaskRepository) SetProject(ids []uint, projectID *uint, workflow *models.Workflow) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Task{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"project_id": projectID, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+		return fitWorkflow(tx, ids, workflow)
+	})
+}
+
+// fitWorkflow moves those of the given tasks whose status is not part of
+// workflow to the status Workflow.Fit picks for them, as a new version.
+func fitWorkflow(db *gorm.DB, ids []uint, workflow *models.Workflow) error {
+	for _, category := range models.StatusCategories {
+		fit := workflow.Fit("", category)
+		err := db.Model(&models.Task{}).
+			Where("id IN ? AND status_category = ? AND status NOT IN ?", ids, category, workflow.StatusNames()).
+			Updates(map[string]interface{}{"status": fit.Name, "status_category": fit.Category, "version": gorm.Expr("version + 1")}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindInBatches walks all of the user's tasks in ID order, handing them to fn
+// a batch at a time so that exports never hold every task in memory.
+func (r *TaskRepository) FindInBatches(userID uint, batchSize int, fn func([]models.Task) error) error {
+	var batch []models.Task
+	return r.db.Where("user_id = ?", userID).Preload("Tags").
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// Import creates a batch of tasks in one transaction. A ParentID that matches
+// the ID a task of the batch was exported with is pointed at that task's new
+// ID; any other ParentID is kept as is. Tags are linked by name among the
+// tags of the task's owner, creating the missing ones. Assignees are not
+// imported.
+func (r *TaskRepository) Import(tasks []models.Task) ([]models.Task, error) {
+	created := make([]models.Task, len(tasks))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		tagRepo := NewTagRepository(tx)
+		newIDs := make(map[uint]uint)
+
+		// Parents may come after their subtasks, so they are linked in a second pass
+		for i, task := range tasks {
+			task.ID, task.Version, task.ParentID, task.Tags, task.Assignees = 0, 1, nil, nil, nil
+			task.DeletedAt = gorm.DeletedAt{}
+			if err := tx.Omit(clause.Associations).Create(&task).Error; err != nil {
+				return err
+			}
+			if tasks[i].ID != 0 {
+				newIDs[tasks[i].ID] = task.ID
+			}
+
+			for _, tag := range tasks[i].Tags {
+				linked, _, err := tagRepo.FindOrCreateByName(task.UserID, tag.Name)
+				if err != nil {
+					return err
+				}
+				if err := tx.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?)", task.ID, linked.ID).Error; err != nil {
+					return err
+				}
+				task.Tags = append(task.Tags, *linked)
+			}
+			created[i] = task
+		}
+
+		for i, task := range tasks {
+			if task.ParentID == nil {
+				continue
+			}
+			parentID := *task.ParentID
+			if newID, ok := newIDs[parentID]; ok {
+				parentID = newID
+			}
+			if err := tx.Model(&models.Task{}).Where("id = ?", created[i].ID).Update("parent_id", parentID).Error; err != nil {
+				return err
+			}
+			created[i].ParentID = &parentID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
 func (r *TaskRepository) AddTag(taskID uint, tagID uint) error {
 	return r.db.Exec("INSERT INTO task_tags (task_id, tag_id) VALUES (?, ?)", taskID, tagID).Error
 }
 
-func (r *TaskRepository) RemoveAllTags(taskID uint) error {
-	return r.db.Exec("DELETE FROM task_tags WHERE task_id = ?", taskID).Error
-}
\ No newline at end of file
+// RemoveUserTags unlinks the tags of one user from a task, leaving those other
+// members of its project put on it.
+func (r *TaskRepository) RemoveUserTags(taskID uint, userID uint) error {
+	return r.db.Exec("DELETE FROM task_tags WHERE task_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ?)", taskID, userID).Error
+}
+
+func (r *TaskRepository) RemoveTag(taskID uint, tagID uint) error {
+	return r.db.Exec("DELETE FROM task_tags WHERE task_id = ? AND tag_id = ?", taskID, tagID).Error
+}
+
+// SetAssignees replaces the users a task is assigned to.
+func (r *TaskRepository) SetAssignees(taskID uint, userIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM task_assignees WHERE task_id = ?", taskID).Error; err != nil {
+			return err
+		}
+		for _, userID := range userIDs {
+			if err := tx.Exec("INSERT INTO task_assignees (task_id, user_id) VALUES (?, ?)", taskID, userID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
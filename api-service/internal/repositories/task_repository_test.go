@@ -0,0 +1,13 @@
+package repositories
+
+import "testing"
+
+func TestLikePatternMatchesLiterally(t *testing.T) {
+	// With ESCAPE '!' every wildcard and the escape itself lose their meaning
+	if got, want := likePattern("100%_done!"), "%100!%!_done!!%"; got != want {
+		t.Errorf("likePattern = %q, want %q", got, want)
+	}
+	if got := escapeLike("work/billing"); got != "work/billing" {
+		t.Errorf("escapeLike changed a plain tag path to %q", got)
+	}
+}
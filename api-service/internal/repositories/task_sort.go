@@ -0,0 +1,296 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+type sortKind int
+
+const (
+	sortInt sortKind = iota
+	sortFloat
+	sortString
+	sortTime
+)
+
+type sortField struct {
+	expr     string
+	args     []any
+	kind     sortKind
+	nullable bool
+	value    func(task *models.Task) any
+}
+
+// sortFields lists the columns a task listing can be ordered by. Priority and
+// status are ranked through CASE expressions so that ordering follows their
+// meaning (LOW < MEDIUM < HIGH) rather than the alphabet.
+var sortFields = map[string]sortField{
+	"due_date": {
+		expr:     "tasks.due_date",
+		kind:     sortTime,
+		nullable: true,
+		value: func(task *models.Task) any {
+			if task.DueDate == nil {
+				return nil
+			}
+			return *task.DueDate
+		},
+	},
+	"priority": {
+		expr:  "CASE tasks.priority WHEN 'LOW' THEN 1 WHEN 'MEDIUM' THEN 2 WHEN 'HIGH' THEN 3 ELSE 0 END",
+		kind:  sortInt,
+		value: func(task *models.Task) any { return priorityRank(task.Priority) },
+	},
+	"status": {
+		expr:  "CASE tasks.status_category WHEN 'todo' THEN 1 WHEN 'doing' THEN 2 WHEN 'done' THEN 3 ELSE 0 END",
+		kind:  sortInt,
+		value: func(task *models.Task) any { return categoryRank(task.Category) },
+	},
+	"created_at": {
+		expr:  "tasks.created_at",
+		kind:  sortTime,
+		value: func(task *models.Task) any { return task.CreatedAt },
+	},
+	"updated_at": {
+		expr:  "tasks.updated_at",
+		kind:  sortTime,
+		value: func(task *models.Task) any { return task.UpdatedAt },
+	},
+	"title": {
+		expr:  "tasks.title",
+		kind:  sortString,
+		value: func(task *models.Task) any { return task.Title },
+	},
+}
+
+func priorityRank(priority models.TaskPriority) int {
+	switch priority {
+	case models.PriorityLow:
+		return 1
+	case models.PriorityMedium:
+		return 2
+	case models.PriorityHigh:
+		return 3
+	}
+	return 0
+}
+
+// categoryRank orders tasks by the category of their status, since the
+// statuses themselves differ between workflows.
+func categoryRank(category models.StatusCategory) int {
+	switch category {
+	case models.CategoryTodo:
+		return 1
+	case models.CategoryDoing:
+		return 2
+	case models.CategoryDone:
+		return 3
+	}
+	return 0
+}
+
+type sortKey struct {
+	sortField
+	desc bool
+}
+
+type taskSort struct {
+	spec string
+	keys []sortKey
+}
+
+// parseSort reads a comma separated list such as "due_date,priority:desc".
+// Tasks without a due date always sort after the dated ones, whichever
+// direction is requested. The "relevance" key is only available while a
+// search is running, in which case relevance is passed in and becomes the
+// default ordering.
+func parseSort(value string, relevance *sortField) (taskSort, error) {
+	var ts taskSort
+	if strings.TrimSpace(value) == "" {
+		if relevance == nil {
+			return ts, nil
+		}
+		value = "relevance:desc"
+	}
+
+	seen := make(map[string]bool)
+	var specs []string
+	for _, part := range strings.Split(value, ",") {
+		name, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		field, ok := sortFields[name]
+		if name == "relevance" && relevance != nil {
+			field, ok = *relevance, true
+		}
+		if !ok || seen[name] {
+			return ts, ErrInvalidSort
+		}
+		seen[name] = true
+
+		desc := false
+		switch strings.ToLower(dir) {
+		case "", "asc":
+		case "desc":
+			desc = true
+		default:
+			return ts, ErrInvalidSort
+		}
+
+		if field.nullable {
+			ts.keys = append(ts.keys, sortKey{sortField: nullsLast(field)})
+		}
+		ts.keys = append(ts.keys, sortKey{sortField: field, desc: desc})
+
+		if desc {
+			specs = append(specs, name+":desc")
+		} else {
+			specs = append(specs, name)
+		}
+	}
+	ts.spec = strings.Join(specs, ",")
+	if relevance != nil {
+		// Relevance scores only line up between pages of the same search
+		ts.spec += "?" + fmt.Sprint(relevance.args...)
+	}
+
+	return ts, nil
+}
+
+func nullsLast(field sortField) sortField {
+	return sortField{
+		expr: fmt.Sprintf("CASE WHEN %s IS NULL THEN 1 ELSE 0 END", field.expr),
+		args: field.args,
+		kind: sortInt,
+		value: func(task *models.Task) any {
+			if field.value(task) == nil {
+				return 1
+			}
+			return 0
+		},
+	}
+}
+
+func (ts taskSort) apply(query *gorm.DB) *gorm.DB {
+	// Built as a single expression since gorm cannot mix raw ORDER BY
+	// expressions carrying arguments with plain columns
+	var terms []string
+	var args []any
+	for _, key := range ts.keys {
+		if key.desc {
+			terms = append(terms, key.expr+" DESC")
+		} else {
+			terms = append(terms, key.expr)
+		}
+		args = append(args, key.args...)
+	}
+	terms = append(terms, "tasks.id")
+
+	return query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                strings.Join(terms, ", "),
+		Vars:               args,
+		WithoutParentheses: true,
+	}})
+}
+
+func (ts taskSort) cursorFor(task *models.Task) taskCursor {
+	cur := taskCursor{Sort: ts.spec, ID: task.ID}
+	for _, key := range ts.keys {
+		value := key.value(task)
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.RFC3339Nano)
+		}
+		cur.Values = append(cur.Values, value)
+	}
+	return cur
+}
+
+// after restricts the query to rows that come strictly after the cursor in
+// the (keys..., id) ordering.
+func (ts taskSort) after(query *gorm.DB, cur taskCursor) (*gorm.DB, error) {
+	if cur.Sort != ts.spec || len(cur.Values) != len(ts.keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]any, len(ts.keys))
+	for i, key := range ts.keys {
+		value, err := cursorValue(key.kind, cur.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	var clauses []string
+	var args []any
+	for i := 0; i <= len(ts.keys); i++ {
+		var parts []string
+		var partArgs []any
+		for j := 0; j < i; j++ {
+			partArgs = append(partArgs, ts.keys[j].args...)
+			if values[j] == nil {
+				parts = append(parts, ts.keys[j].expr+" IS NULL")
+			} else {
+				parts = append(parts, ts.keys[j].expr+" = ?")
+				partArgs = append(partArgs, values[j])
+			}
+		}
+
+		if i == len(ts.keys) {
+			parts = append(parts, "tasks.id > ?")
+			partArgs = append(partArgs, cur.ID)
+		} else {
+			// Rows sharing a NULL value are only ordered by the keys that follow
+			if values[i] == nil {
+				continue
+			}
+			op := " > ?"
+			if ts.keys[i].desc {
+				op = " < ?"
+			}
+			parts = append(parts, ts.keys[i].expr+op)
+			partArgs = append(partArgs, ts.keys[i].args...)
+			partArgs = append(partArgs, values[i])
+		}
+
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+		args = append(args, partArgs...)
+	}
+
+	return query.Where(strings.Join(clauses, " OR "), args...), nil
+}
+
+func cursorValue(kind sortKind, raw any) (any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	switch kind {
+	case sortInt:
+		if n, ok := raw.(float64); ok {
+			return int64(n), nil
+		}
+	case sortFloat:
+		if n, ok := raw.(float64); ok {
+			return n, nil
+		}
+	case sortString:
+		if s, ok := raw.(string); ok {
+			return s, nil
+		}
+	case sortTime:
+		if s, ok := raw.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return nil, ErrInvalidCursor
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+)
+
+func TestParseSortSpec(t *testing.T) {
+	valid := map[string]string{
+		"":                             "",
+		"title":                        "title",
+		" due_date:DESC , priority ":   "due_date:desc,priority",
+		"status:asc,created_at:desc":   "status,created_at:desc",
+		"updated_at,title:desc,status": "updated_at,title:desc,status",
+	}
+	for value, want := range valid {
+		ts, err := parseSort(value, nil)
+		if err != nil {
+			t.Errorf("parseSort(%q): %v", value, err)
+			continue
+		}
+		if ts.spec != want {
+			t.Errorf("parseSort(%q).spec = %q, want %q", value, ts.spec, want)
+		}
+	}
+
+	for _, value := range []string{"owner", "title,title", "title:up", "relevance", "title,"} {
+		if _, err := parseSort(value, nil); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("parseSort(%q): err = %v, want ErrInvalidSort", value, err)
+		}
+	}
+}
+
+// The values of a cursor pass through JSON on their way to the client and
+// back, so they must come out of cursorValue as they went into cursorFor.
+func TestSortCursorSurvivesEncoding(t *testing.T) {
+	due := time.Date(2024, time.March, 1, 17, 30, 0, 123456789, time.UTC)
+	task := models.Task{ID: 9, Title: "Write the report", DueDate: &due, Priority: models.PriorityHigh}
+
+	ts, err := parseSort("due_date:desc,priority,title", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := decodeCursor(encodeCursor(ts.cursorFor(&task)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur.Sort != ts.spec || cur.ID != task.ID || len(cur.Values) != len(ts.keys) {
+		t.Fatalf("decoded cursor = %+v", cur)
+	}
+
+	// due_date brings along the key that puts undated tasks last
+	want := []any{int64(0), due, int64(3), "Write the report"}
+	for i, key := range ts.keys {
+		got, err := cursorValue(key.kind, cur.Values[i])
+		if err != nil {
+			t.Fatalf("value %d: %v", i, err)
+		}
+		if at, ok := got.(time.Time); ok {
+			if !at.Equal(due) {
+				t.Errorf("value %d = %s, want %s", i, at, due)
+			}
+		} else if got != want[i] {
+			t.Errorf("value %d = %#v, want %#v", i, got, want[i])
+		}
+	}
+}
+
+func TestSortCursorWithoutDueDate(t *testing.T) {
+	ts, _ := parseSort("due_date", nil)
+	cur := ts.cursorFor(&models.Task{ID: 4})
+
+	if len(cur.Values) != 2 || cur.Values[0] != 1 || cur.Values[1] != nil {
+		t.Errorf("cursor values = %#v, want [1 <nil>]", cur.Values)
+	}
+}
+
+func TestAfterRejectsForeignCursors(t *testing.T) {
+	byTitle, _ := parseSort("title", nil)
+	byDue, _ := parseSort("due_date", nil)
+
+	fromTitle := byTitle.cursorFor(&models.Task{ID: 3, Title: "a"})
+	if _, err := byDue.after(nil, fromTitle); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("cursor of another sort: err = %v, want ErrInvalidCursor", err)
+	}
+
+	tampered := taskCursor{Sort: "title", Values: []any{3.0}, ID: 3}
+	if _, err := byTitle.after(nil, tampered); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("number for a title: err = %v, want ErrInvalidCursor", err)
+	}
+
+	stale := taskCursor{Sort: "due_date", Values: []any{0.0, "yesterday"}, ID: 3}
+	if _, err := byDue.after(nil, stale); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("unparsable due date: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// maxTxAttempts bounds how often a transaction is run while it keeps losing
+// races with concurrent ones.
+const maxTxAttempts = 3
+
+// MySQL errors after which a transaction can simply be run again, and the
+// one for a row that already exists.
+const (
+	errLockWaitTimeout = 1205
+	errDeadlock        = 1213
+	errDuplicateKey    = 1062
+)
+
+// errCreateRace marks a duplicate key from creating a row that a concurrent
+// transaction created after it was looked for, see lostCreateRace.
+var errCreateRace = errors.New("row created concurrently")
+
+// transaction runs fn inside a transaction on db, running it again from the
+// start when it fails on a conflict with a concurrent transaction. fn must
+// therefore be safe to call more than once, and db must not already be in a
+// transaction: MySQL rolls the whole of it back on a deadlock.
+func transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	for attempt := 1; ; attempt++ {
+		err := db.Transaction(fn)
+		if err == nil || attempt == maxTxAttempts || !retryable(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 20 * time.Millisecond)
+	}
+}
+
+// retryable reports whether err is a deadlock, a lock wait timeout, or a
+// duplicate key from two transactions finding and then creating the same row
+// at once, like the same new tag for two tasks. Other duplicate keys are
+// real conflicts that running again would not resolve.
+func retryable(err error) bool {
+	if errors.Is(err, errCreateRace) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == errLockWaitTimeout || mysqlErr.Number == errDeadlock
+}
+
+// IsDuplicate reports whether err comes from writing a row that conflicts
+// with an existing one on a unique key.
+func IsDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateKey
+}
+
+// lostCreateRace marks err as worth running the transaction again for when
+// it is a duplicate key, for find-or-create paths whose row appeared between
+// the find and the create.
+func lostCreateRace(err error) error {
+	if IsDuplicate(err) {
+		return fmt.Errorf("%w: %w", errCreateRace, err)
+	}
+	return err
+}
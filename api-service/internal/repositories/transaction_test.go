@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRetryable(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: errDuplicateKey, Message: "Duplicate entry"}
+
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+		duplicate bool
+	}{
+		{"deadlock", &mysql.MySQLError{Number: errDeadlock}, true, false},
+		{"lock wait timeout", fmt.Errorf("saving: %w", &mysql.MySQLError{Number: errLockWaitTimeout}), true, false},
+		{"duplicate key", duplicate, false, true},
+		{"lost create race", lostCreateRace(duplicate), true, true},
+		{"other error in a create", lostCreateRace(&mysql.MySQLError{Number: 1406}), false, false},
+		{"not mysql", errors.New("connection refused"), false, false},
+		{"version conflict", ErrVersionConflict, false, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.retryable {
+			t.Errorf("%s: retryable = %t, want %t", tt.name, got, tt.retryable)
+		}
+		if got := IsDuplicate(tt.err); got != tt.duplicate {
+			t.Errorf("%s: IsDuplicate = %t, want %t", tt.name, got, tt.duplicate)
+		}
+	}
+}
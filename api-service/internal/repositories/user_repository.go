@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByUsernames returns the users with the given usernames. Names without
+// an account are left out.
+func (r *UserRepository) FindByUsernames(usernames []string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrDeliveryLeaseLost is returned when a delivery is marked by a replica
+// whose claim on it ran out, so that another one may be sending it too.
+var ErrDeliveryLeaseLost = errors.New("webhook delivery lease lost")
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) FindEndpointsByUserID(userID uint) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhookRepository) FindEndpointByID(id uint, userID uint) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&endpoint).Error
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepository) CreateEndpoint(endpoint models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	err := r.db.Create(&endpoint).Error
+	return &endpoint, err
+}
+
+func (r *WebhookRepository) UpdateEndpoint(endpoint models.WebhookEndpoint) (*models.WebhookEndpoint, error) {
+	err := r.db.Save(&endpoint).Error
+	return &endpoint, err
+}
+
+// DeleteEndpoint removes an endpoint together with its delivery log.
+func (r *WebhookRepository) DeleteEndpoint(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookEndpoint{}, id).Error
+	})
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	err := r.db.Create(&delivery).Error
+	return &delivery, err
+}
+
+// FindDeliveries returns the most recent deliveries of an endpoint first.
+func (r *WebhookRepository) FindDeliveries(endpointID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("endpoint_id = ?", endpointID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *WebhookRepository) FindDeliveryByID(id uint, endpointID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Where("id = ? AND endpoint_id = ?", id, endpointID).First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ClaimNext leases the next pending delivery to owner, with its endpoint
+// loaded, or returns nil when none is due. Claims are taken with a
+// conditional update so that only one replica ever wins a given delivery.
+func (r *WebhookRepository) ClaimNext(owner string, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	until := now.Add(lease)
+	for {
+		var delivery models.WebhookDelivery
+		err := r.db.Preload("Endpoint").
+			Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?) AND (claimed_until IS NULL OR claimed_until < ?)",
+				models.DeliveryPending, now, now).
+			Order("id").
+			First(&delivery).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Another replica may have claimed it since; then move on to the next one
+		result := r.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND (claimed_until IS NULL OR claimed_until < ?)", delivery.ID, models.DeliveryPending, now).
+			Updates(map[string]interface{}{"claimed_by": owner, "claimed_until": until})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.ClaimedBy = owner
+			delivery.ClaimedUntil = &until
+			return &delivery, nil
+		}
+	}
+}
+
+// MarkDelivered closes a delivery for good. It returns ErrDeliveryLeaseLost
+// when owner's lease on the delivery ran out before deliveredAt.
+func (r *WebhookRepository) MarkDelivered(id uint, owner string, responseCode int, deliveredAt time.Time) error {
+	result := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND claimed_by = ? AND claimed_until >= ?", id, models.DeliveryPending, owner, deliveredAt).
+		Updates(map[string]interface{}{
+			"status":        models.DeliveryDelivered,
+			"attempts":      gorm.Expr("attempts + 1"),
+			"response_code": responseCode,
+			"last_error":    "",
+			"delivered_at":  deliveredAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeliveryLeaseLost
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt. The delivery is retried at retryAt, or
+// given up on for good when retryAt is nil. responseCode is nil when no
+// response was received at all. Like MarkDelivered it returns
+// ErrDeliveryLeaseLost when owner's lease ran out before failedAt.
+func (r *WebhookRepository) MarkFailed(id uint, owner string, responseCode *int, deliveryErr error, failedAt time.Time, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"response_code":   responseCode,
+		"last_error":      deliveryErr.Error(),
+		"next_attempt_at": retryAt,
+	}
+	if retryAt == nil {
+		updates["status"] = models.DeliveryFailed
+	}
+	result := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND claimed_by = ? AND claimed_until >= ?", id, models.DeliveryPending, owner, failedAt).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeliveryLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"fmt"
+
+	"taskmango/apisvc/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StatusInUseError is returned when a workflow would drop statuses that
+// tasks of the project are still in.
+type StatusInUseError struct {
+	Statuses []models.TaskStatus
+}
+
+func (e *StatusInUseError) Error() string {
+	return fmt.Sprintf("statuses still in use: %v", e.Statuses)
+}
+
+type WorkflowRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRepository(db *gorm.DB) *WorkflowRepository {
+	return &WorkflowRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that works inside tx.
+func (r *WorkflowRepository) WithTx(tx *gorm.DB) *WorkflowRepository {
+	return &WorkflowRepository{db: tx}
+}
+
+// FindByProjectID returns the workflow of a project, the default one when
+// the project has none of its own.
+func (r *WorkflowRepository) FindByProjectID(projectID uint) (*models.Workflow, error) {
+	var statuses []models.WorkflowStatus
+	if err := r.db.Where("project_id = ?", projectID).Order("position").Find(&statuses).Error; err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return models.DefaultWorkflow(), nil
+	}
+
+	transitions := []models.WorkflowTransition{}
+	err := r.db.Where("project_id = ?", projectID).Order("from_status, to_status").Find(&transitions).Error
+	if err != nil {
+		return nil, err
+	}
+	return &models.Workflow{Custom: true, Statuses: statuses, Transitions: transitions}, nil
+}
+
+// Hold locks the row of a project in share mode, so that its workflow stays
+// the way it is read until the surrounding transaction ends. Save takes the
+// same row for update.
+func (r *WorkflowRepository) Hold(projectID uint) error {
+	return r.db.Clauses(clause.Locking{Strength: "SHARE"}).Select("id").Find(&models.Project{}, projectID).Error
+}
+
+// Save replaces the workflow of a project, or goes back to the default one
+// when workflow is nil, and gives the project's tasks the spelling and
+// category their statuses now have. Dropping a status that tasks, trashed
+// ones included, are still in fails with a StatusInUseError. Changes to one
+// project are serialized by locking its row.
+func (r *WorkflowRepository) Save(projectID uint, workflow *models.Workflow) error {
+	custom := workflow != nil
+	if !custom {
+		workflow = models.DefaultWorkflow()
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var project models.Project
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, projectID).Error; err != nil {
+			return err
+		}
+
+		var dropped []models.TaskStatus
+		err := tx.Unscoped().Model(&models.Task{}).Distinct("status").
+			Where("project_id = ? AND status NOT IN ?", projectID, workflow.StatusNames()).
+			Order("status").Pluck("status", &dropped).Error
+		if err != nil {
+			return err
+		}
+		if len(dropped) > 0 {
+			return &StatusInUseError{Statuses: dropped}
+		}
+
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.WorkflowStatus{}).Error; err != nil {
+			return err
+		}
+		if custom {
+			statuses := make([]models.WorkflowStatus, len(workflow.Statuses))
+			for i, status := range workflow.Statuses {
+				status.ProjectID, status.Position = projectID, i
+				statuses[i] = status
+			}
+			if err := tx.Create(&statuses).Error; err != nil {
+				return err
+			}
+
+			if len(workflow.Transitions) > 0 {
+				transitions := make([]models.WorkflowTransition, len(workflow.Transitions))
+				for i, transition := range workflow.Transitions {
+					transition.ProjectID = projectID
+					transitions[i] = transition
+				}
+				if err := tx.Create(&transitions).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Renamed by case or moved to another category; either bumps the version
+		for _, status := range workflow.Statuses {
+			err := tx.Unscoped().Model(&models.Task{}).
+				Where("project_id = ? AND status = ? AND (BINARY status <> ? OR status_category <> ?)",
+					projectID, status.Name, status.Name, status.Category).
+				Updates(map[string]interface{}{"status": status.Name, "status_category": status.Category, "version": gorm.Expr("version + 1")}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -2,25 +2,31 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
+	"taskmango/apisvc/internal/authz"
 	"taskmango/apisvc/internal/config"
 	"taskmango/apisvc/internal/controllers"
 	"taskmango/apisvc/internal/middlewares"
+	"taskmango/apisvc/internal/models"
 	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/storage"
+	"taskmango/apisvc/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
+func SetupRouter(db *gorm.DB, cfg *config.Config, store storage.Store) *gin.Engine {
 	router := gin.Default()
 
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "false")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, ETag")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -31,12 +37,42 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 	// Initialize repositories
 	taskRepo := repositories.NewTaskRepository(db)
 	tagRepo := repositories.NewTagRepository(db)
+	dependencyRepo := repositories.NewDependencyRepository(db)
+	reminderRepo := repositories.NewReminderRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	calendarFeedRepo := repositories.NewCalendarFeedRepository(db)
+	historyRepo := repositories.NewHistoryRepository(db)
+	commentRepo := repositories.NewCommentRepository(db)
+	attachmentRepo := repositories.NewAttachmentRepository(db)
+	projectRepo := repositories.NewProjectRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	workflowRepo := repositories.NewWorkflowRepository(db)
+
+	// Initialize webhook dispatcher
+	dispatcher := webhooks.NewDispatcher(webhookRepo)
 
 	// Initialize middleware
 	authMiddleware := middlewares.AuthMiddleware(cfg)
+	authorizer := authz.NewAuthorizer(taskRepo, projectRepo)
+	taskViewer := middlewares.RequireTaskRole(authorizer, models.RoleViewer)
+	taskEditor := middlewares.RequireTaskRole(authorizer, models.RoleEditor)
+	projectViewer := middlewares.RequireProjectRole(authorizer, models.RoleViewer)
+	projectOwner := middlewares.RequireProjectRole(authorizer, models.RoleOwner)
 
 	// Initialize controllers
-	taskController := controllers.NewTaskController(taskRepo, tagRepo)
+	taskController := controllers.NewTaskController(taskRepo, tagRepo, dependencyRepo, reminderRepo, historyRepo, projectRepo, workflowRepo, userRepo,
+		authorizer, dispatcher)
+	webhookController := controllers.NewWebhookController(webhookRepo)
+	calendarController := controllers.NewCalendarController(calendarFeedRepo, taskRepo)
+	commentController := controllers.NewCommentController(commentRepo, taskRepo)
+	attachmentController := controllers.NewAttachmentController(attachmentRepo, taskRepo, store, int64(cfg.AttachmentMaxSize))
+	tagController := controllers.NewTagController(tagRepo, historyRepo, dispatcher)
+	projectController := controllers.NewProjectController(projectRepo, historyRepo, userRepo)
+	workflowController := controllers.NewWorkflowController(workflowRepo)
+	trashController := controllers.NewTrashController(taskRepo, historyRepo, authorizer, dispatcher, time.Duration(cfg.TrashRetentionDays)*24*time.Hour)
+
+	// Calendar apps cannot send a JWT, the feed token in the path authenticates them
+	router.GET("/api/calendar/:token", calendarController.ServeFeed)
 
 	// API routes
 	apiGroup := router.Group("/api")
@@ -46,14 +82,84 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		tasksGroup := apiGroup.Group("/tasks")
 		{
 			tasksGroup.GET("", taskController.GetTasks)
-			tasksGroup.GET("/:id", taskController.GetTaskByID)
+			tasksGroup.GET("/export", taskController.ExportTasks)
+			tasksGroup.POST("/import", taskController.ImportTasks)
+			tasksGroup.POST("/bulk", taskController.BulkTasks)
+			tasksGroup.GET("/trash", trashController.GetTrash)
+			tasksGroup.GET("/:id", taskViewer, taskController.GetTaskByID)
 			tasksGroup.POST("", taskController.CreateTask)
-			tasksGroup.PUT("/:id", taskController.UpdateTask)
-			tasksGroup.DELETE("/:id", taskController.DeleteTask)
+			tasksGroup.PUT("/:id", taskEditor, taskController.UpdateTask)
+			tasksGroup.PATCH("/:id", taskEditor, taskController.PatchTask)
+			tasksGroup.DELETE("/:id", taskEditor, taskController.DeleteTask)
+			tasksGroup.POST("/:id/restore", trashController.RestoreTask)
+			tasksGroup.GET("/:id/subtasks", taskViewer, taskController.GetSubtasks)
+			tasksGroup.POST("/:id/move", taskEditor, taskController.MoveTask)
+			tasksGroup.GET("/:id/dependencies", taskViewer, taskController.GetDependencies)
+			tasksGroup.POST("/:id/dependencies", taskEditor, taskController.AddDependency)
+			tasksGroup.DELETE("/:id/dependencies/:dependsOnId", taskEditor, taskController.RemoveDependency)
+			tasksGroup.GET("/:id/occurrences", taskViewer, taskController.GetOccurrences)
+			tasksGroup.GET("/:id/history", taskViewer, taskController.GetHistory)
+			tasksGroup.GET("/:id/comments", taskViewer, commentController.GetComments)
+			tasksGroup.POST("/:id/comments", taskEditor, commentController.CreateComment)
+			tasksGroup.PUT("/:id/comments/:commentId", taskEditor, commentController.UpdateComment)
+			tasksGroup.DELETE("/:id/comments/:commentId", taskEditor, commentController.DeleteComment)
+			tasksGroup.GET("/:id/attachments", taskViewer, attachmentController.GetAttachments)
+			tasksGroup.POST("/:id/attachments", taskEditor, attachmentController.UploadAttachment)
+			tasksGroup.GET("/:id/attachments/:attachmentId", taskViewer, attachmentController.DownloadAttachment)
+			tasksGroup.DELETE("/:id/attachments/:attachmentId", taskEditor, attachmentController.DeleteAttachment)
+			// Reminders are personal, viewers may remind themselves
+			tasksGroup.GET("/:id/reminders", taskViewer, taskController.GetReminders)
+			tasksGroup.POST("/:id/reminders", taskViewer, taskController.CreateReminder)
+			tasksGroup.DELETE("/:id/reminders/:reminderId", taskViewer, taskController.DeleteReminder)
 		}
 
-		// Tags endpoint
-		apiGroup.GET("/tags", taskController.GetTags)
+		// Projects endpoints
+		projectsGroup := apiGroup.Group("/projects")
+		{
+			projectsGroup.GET("", projectController.GetProjects)
+			projectsGroup.GET("/:id", projectViewer, projectController.GetProjectByID)
+			projectsGroup.POST("", projectController.CreateProject)
+			projectsGroup.PUT("/:id", projectOwner, projectController.UpdateProject)
+			projectsGroup.DELETE("/:id", projectOwner, projectController.DeleteProject)
+			projectsGroup.POST("/:id/archive", projectOwner, projectController.ArchiveProject)
+			projectsGroup.POST("/:id/unarchive", projectOwner, projectController.UnarchiveProject)
+			projectsGroup.GET("/:id/members", projectViewer, projectController.GetMembers)
+			projectsGroup.POST("/:id/members", projectOwner, projectController.AddMember)
+			projectsGroup.PUT("/:id/members/:userId", projectOwner, projectController.UpdateMember)
+			// Members may leave on their own, the handler checks the rest
+			projectsGroup.DELETE("/:id/members/:userId", projectViewer, projectController.RemoveMember)
+			projectsGroup.GET("/:id/workflow", projectViewer, workflowController.GetWorkflow)
+			projectsGroup.PUT("/:id/workflow", projectOwner, workflowController.UpdateWorkflow)
+			projectsGroup.DELETE("/:id/workflow", projectOwner, workflowController.ResetWorkflow)
+		}
+
+		// Tags endpoints
+		tagsGroup := apiGroup.Group("/tags")
+		{
+			tagsGroup.GET("", tagController.GetTags)
+			tagsGroup.GET("/:id", tagController.GetTagByID)
+			tagsGroup.POST("", tagController.CreateTag)
+			tagsGroup.PUT("/:id", tagController.UpdateTag)
+			tagsGroup.DELETE("/:id", tagController.DeleteTag)
+			tagsGroup.POST("/:id/merge", tagController.MergeTag)
+		}
+
+		// Webhooks endpoints
+		webhooksGroup := apiGroup.Group("/webhooks")
+		{
+			webhooksGroup.GET("", webhookController.GetWebhooks)
+			webhooksGroup.GET("/:id", webhookController.GetWebhookByID)
+			webhooksGroup.POST("", webhookController.CreateWebhook)
+			webhooksGroup.PUT("/:id", webhookController.UpdateWebhook)
+			webhooksGroup.DELETE("/:id", webhookController.DeleteWebhook)
+			webhooksGroup.GET("/:id/deliveries", webhookController.GetDeliveries)
+			webhooksGroup.POST("/:id/deliveries/:deliveryId/redeliver", webhookController.Redeliver)
+		}
+
+		// Calendar feed endpoints
+		apiGroup.GET("/calendar/feed", calendarController.GetFeed)
+		apiGroup.POST("/calendar/feed", calendarController.CreateFeed)
+		apiGroup.DELETE("/calendar/feed", calendarController.DeleteFeed)
 	}
 
 	return router
@@ -88,4 +194,4 @@ func SetupProbeRouter(db *gorm.DB) *gin.Engine {
 	})
 
 	return router
-}
\ No newline at end of file
+}
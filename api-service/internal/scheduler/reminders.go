@@ -0,0 +1,134 @@
+// Package scheduler runs the background jobs of the API service. Every job
+// is safe to run on several replicas at once.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/notifiers"
+	"taskmango/apisvc/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+const (
+	reminderBatchSize   = 100
+	maxReminderAttempts = 5
+)
+
+type ReminderScheduler struct {
+	reminderRepo *repositories.ReminderRepository
+	taskRepo     *repositories.TaskRepository
+	notifiers    map[models.ReminderChannel]notifiers.Notifier
+	owner        string
+	interval     time.Duration
+	lease        time.Duration
+}
+
+func NewReminderScheduler(reminderRepo *repositories.ReminderRepository, taskRepo *repositories.TaskRepository,
+	notifiers map[models.ReminderChannel]notifiers.Notifier, interval time.Duration, lease time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminderRepo: reminderRepo,
+		taskRepo:     taskRepo,
+		notifiers:    notifiers,
+		owner:        instanceID(),
+		interval:     interval,
+		lease:        lease,
+	}
+}
+
+// Run polls for due reminders until ctx is cancelled.
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.fireDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fireDue delivers up to a batch of due reminders, claiming each one just
+// before it is sent so that its lease starts with its delivery.
+func (s *ReminderScheduler) fireDue(ctx context.Context) {
+	for i := 0; i < reminderBatchSize && ctx.Err() == nil; i++ {
+		reminder, err := s.reminderRepo.ClaimNext(s.owner, time.Now(), s.lease)
+		if err != nil {
+			log.Printf("Failed to claim due reminders: %v", err)
+			return
+		}
+		if reminder == nil {
+			return
+		}
+
+		if err := s.fire(ctx, *reminder); err != nil {
+			log.Printf("Failed to record delivery of reminder %d: %v", reminder.ID, err)
+		}
+	}
+}
+
+func (s *ReminderScheduler) fire(ctx context.Context, reminder models.Reminder) error {
+	task, err := s.taskRepo.FindByID(reminder.TaskID, reminder.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.reminderRepo.MarkFired(reminder.ID, s.owner, time.Now(), "task no longer exists")
+	}
+	if err != nil {
+		return err
+	}
+
+	notifier, ok := s.notifiers[reminder.Channel]
+	if !ok {
+		return s.reminderRepo.MarkFired(reminder.ID, s.owner, time.Now(), fmt.Sprintf("no notifier for channel %q", reminder.Channel))
+	}
+
+	// Finish well within the lease so no other replica picks the reminder up meanwhile
+	deliverCtx, cancel := context.WithTimeout(ctx, s.lease/2)
+	defer cancel()
+
+	err = notifier.Notify(deliverCtx, reminder.Target, notifiers.Notification{Reminder: reminder, Task: *task})
+	if err == nil {
+		return s.reminderRepo.MarkFired(reminder.ID, s.owner, time.Now(), "")
+	}
+
+	log.Printf("Delivery of reminder %d failed: %v", reminder.ID, err)
+	if reminder.Attempts+1 >= maxReminderAttempts {
+		return s.reminderRepo.MarkFired(reminder.ID, s.owner, time.Now(), err.Error())
+	}
+	return s.reminderRepo.MarkFailed(reminder.ID, s.owner, err, time.Now().Add(backoff(reminder.Attempts)))
+}
+
+// backoff doubles the wait after every failed attempt, starting at a minute
+// and capped at an hour.
+func backoff(attempts int) time.Duration {
+	delay := time.Minute << attempts
+	if delay <= 0 || delay > time.Hour {
+		return time.Hour
+	}
+	return delay
+}
+
+// instanceID names this process in claims so that replicas can tell their
+// leases apart.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "api-service"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
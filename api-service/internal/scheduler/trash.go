@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/storage"
+)
+
+const trashPurgeBatchSize = 500
+
+// TrashPurger permanently deletes tasks that have been in the trash for
+// longer than the retention period, along with the stored files of their
+// attachments. Purging is idempotent, so replicas may run it side by side
+// without claiming work.
+type TrashPurger struct {
+	taskRepo       *repositories.TaskRepository
+	attachmentRepo *repositories.AttachmentRepository
+	store          storage.Store
+	retention      time.Duration
+	interval       time.Duration
+}
+
+func NewTrashPurger(taskRepo *repositories.TaskRepository, attachmentRepo *repositories.AttachmentRepository, store storage.Store,
+	retention time.Duration, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		taskRepo:       taskRepo,
+		attachmentRepo: attachmentRepo,
+		store:          store,
+		retention:      retention,
+		interval:       interval,
+	}
+}
+
+// Run purges expired tasks until ctx is cancelled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	cutoff := time.Now().Add(-p.retention)
+	total := 0
+	for ctx.Err() == nil {
+		ids, err := p.taskRepo.FindPurgeable(cutoff, trashPurgeBatchSize)
+		if err != nil {
+			log.Printf("Failed to find tasks to purge: %v", err)
+			return
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		// The attachment rows go with their tasks, so their keys are read first
+		attachments, err := p.attachmentRepo.FindByTaskIDs(ids)
+		if err != nil {
+			log.Printf("Failed to find attachments to purge: %v", err)
+			return
+		}
+		purged, err := p.taskRepo.Purge(ids)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+			return
+		}
+		p.deleteBlobs(ctx, attachments, purged)
+
+		total += len(purged)
+		if len(ids) < trashPurgeBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("Purged %d tasks from the trash", total)
+	}
+}
+
+// deleteBlobs removes the stored files of the attachments of purged tasks.
+// A file that cannot be removed is left behind and logged.
+func (p *TrashPurger) deleteBlobs(ctx context.Context, attachments []models.Attachment, purged []uint) {
+	gone := make(map[uint]bool, len(purged))
+	for _, id := range purged {
+		gone[id] = true
+	}
+	for _, attachment := range attachments {
+		if !gone[attachment.TaskID] {
+			continue
+		}
+		if err := p.store.Delete(ctx, attachment.StorageKey); err != nil {
+			log.Printf("Failed to delete file of attachment %d: %v", attachment.ID, err)
+		}
+	}
+}
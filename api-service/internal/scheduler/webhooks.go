@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+	"taskmango/apisvc/internal/webhooks"
+)
+
+const (
+	webhookBatchSize   = 100
+	maxWebhookAttempts = 8
+)
+
+var errEndpointGone = errors.New("webhook endpoint no longer exists")
+
+// WebhookWorker sends the deliveries queued by webhooks.Dispatcher, retrying
+// failed ones with exponential backoff.
+type WebhookWorker struct {
+	webhookRepo *repositories.WebhookRepository
+	sender      *webhooks.Sender
+	owner       string
+	interval    time.Duration
+	lease       time.Duration
+}
+
+func NewWebhookWorker(webhookRepo *repositories.WebhookRepository, sender *webhooks.Sender, interval time.Duration, lease time.Duration) *WebhookWorker {
+	return &WebhookWorker{
+		webhookRepo: webhookRepo,
+		sender:      sender,
+		owner:       instanceID(),
+		interval:    interval,
+		lease:       lease,
+	}
+}
+
+// Run polls for pending deliveries until ctx is cancelled.
+func (w *WebhookWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.sendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue sends up to a batch of pending deliveries, claiming each one just
+// before it is sent so that its lease starts with its attempt.
+func (w *WebhookWorker) sendDue(ctx context.Context) {
+	for i := 0; i < webhookBatchSize && ctx.Err() == nil; i++ {
+		delivery, err := w.webhookRepo.ClaimNext(w.owner, time.Now(), w.lease)
+		if err != nil {
+			log.Printf("Failed to claim webhook deliveries: %v", err)
+			return
+		}
+		if delivery == nil {
+			return
+		}
+
+		if err := w.send(ctx, *delivery); err != nil {
+			log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+func (w *WebhookWorker) send(ctx context.Context, delivery models.WebhookDelivery) error {
+	if delivery.Endpoint == nil {
+		return w.webhookRepo.MarkFailed(delivery.ID, w.owner, nil, errEndpointGone, time.Now(), nil)
+	}
+
+	// Finish well within the lease so no other replica picks the delivery up meanwhile
+	sendCtx, cancel := context.WithTimeout(ctx, w.lease/2)
+	defer cancel()
+
+	code, err := w.sender.Send(sendCtx, delivery.Endpoint, delivery)
+	if err == nil {
+		return w.webhookRepo.MarkDelivered(delivery.ID, w.owner, code, time.Now())
+	}
+
+	var responseCode *int
+	if code != 0 {
+		responseCode = &code
+	}
+
+	failedAt := time.Now()
+	var retryAt *time.Time
+	if delivery.Attempts+1 < maxWebhookAttempts {
+		next := failedAt.Add(backoff(delivery.Attempts))
+		retryAt = &next
+	}
+	return w.webhookRepo.MarkFailed(delivery.ID, w.owner, responseCode, err, failedAt, retryAt)
+}
@@ -0,0 +1,21 @@
+package search_test
+
+import (
+	"fmt"
+
+	"taskmango/apisvc/internal/search"
+)
+
+func ExampleTerms() {
+	fmt.Printf("%q\n", search.Terms("Release-notes for v2.1, RELEASE day!"))
+	// Output: ["release" "notes" "for" "v2" "1" "day"]
+}
+
+func ExampleHighlight() {
+	terms := search.Terms("report")
+	fmt.Println(search.Highlight("Send the <b>Report</b> before the reporting call", terms))
+	fmt.Printf("%q\n", search.Highlight("Quarterly budget", terms))
+	// Output:
+	// Send the &lt;b&gt;<mark>Report</mark>&lt;/b&gt; before the <mark>report</mark>ing call
+	// ""
+}
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const snippetRadius = 60
+
+// Terms splits a search query into the lower-cased words it contains.
+func Terms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, field := range fields {
+		if !seen[field] {
+			seen[field] = true
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+// Highlight returns an HTML-escaped snippet of text centred on the first
+// matching term, with every match wrapped in <mark> tags. It returns an empty
+// string when none of the terms occur in text.
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lower-casing changed the length, fall back to case-sensitive matching
+		lower = runes
+	}
+
+	type match struct{ start, end int }
+	var matches []match
+	for i := 0; i < len(lower); {
+		length := 0
+		for _, term := range terms {
+			t := []rune(term)
+			if len(t) > length && hasPrefixAt(lower, t, i) {
+				length = len(t)
+			}
+		}
+		if length > 0 {
+			matches = append(matches, match{i, i + length})
+			i += length
+		} else {
+			i++
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	start := max(matches[0].start-snippetRadius, 0)
+	end := min(matches[0].end+snippetRadius, len(runes))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m.start < start || m.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:m.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		b.WriteString("</mark>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+func hasPrefixAt(text, prefix []rune, at int) bool {
+	if at+len(prefix) > len(text) {
+		return false
+	}
+	for i, r := range prefix {
+		if text[at+i] != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightPrefersLongestTerm(t *testing.T) {
+	got := Highlight("reporting", []string{"report", "reporting"})
+	if want := "<mark>reporting</mark>"; got != want {
+		t.Errorf("Highlight = %q, want %q", got, want)
+	}
+}
+
+func TestHighlightKeepsCaseOfMultibyteText(t *testing.T) {
+	got := Highlight("Café meeting", Terms("CAFÉ"))
+	if want := "<mark>Café</mark> meeting"; got != want {
+		t.Errorf("Highlight = %q, want %q", got, want)
+	}
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	before := strings.Repeat("a", 100)
+	after := strings.Repeat("b", 100)
+	got := Highlight(before+" needle "+after, []string{"needle"})
+
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Fatalf("Highlight = %q, want a snippet cut on both sides", got)
+	}
+	inner := strings.TrimSuffix(strings.TrimPrefix(got, "…"), "…")
+	want := strings.Repeat("a", snippetRadius-1) + " <mark>needle</mark> " + strings.Repeat("b", snippetRadius-1)
+	if inner != want {
+		t.Errorf("snippet = %q, want %q", inner, want)
+	}
+}
@@ -0,0 +1,39 @@
+// Package storage keeps the contents of attachments out of the database. A
+// Store saves blobs under slash-separated keys; which backend is used is a
+// deployment decision made in the configuration.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+type Store interface {
+	// Put saves size bytes read from r under key, replacing any blob there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob under key, or returns ErrNotFound.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is no error.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey accepts keys made of non-empty segments that cannot climb out of
+// the store's root.
+func validKey(key string) bool {
+	if key == "" || strings.ContainsRune(key, '\\') {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+// Package webhooks publishes task lifecycle events to the endpoints users
+// register. Emitting only records a delivery; the scheduler's webhook worker
+// sends it, so a slow or failing endpoint never holds up an API request.
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"taskmango/apisvc/internal/models"
+	"taskmango/apisvc/internal/repositories"
+)
+
+// Event is the JSON body of every delivery.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type Dispatcher struct {
+	webhookRepo *repositories.WebhookRepository
+}
+
+func NewDispatcher(webhookRepo *repositories.WebhookRepository) *Dispatcher {
+	return &Dispatcher{webhookRepo: webhookRepo}
+}
+
+// Emit queues event for every active endpoint of the user subscribed to it.
+// Failures are logged rather than returned: the change that caused the event
+// has already been made and must not be reported as failed.
+func (d *Dispatcher) Emit(userID uint, eventType string, data interface{}) {
+	endpoints, err := d.webhookRepo.FindEndpointsByUserID(userID)
+	if err != nil {
+		log.Printf("Failed to look up webhook endpoints for %s: %v", eventType, err)
+		return
+	}
+
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Active || !endpoint.Subscribes(eventType) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(Event{ID: newEventID(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data})
+			if err != nil {
+				log.Printf("Failed to encode %s event: %v", eventType, err)
+				return
+			}
+		}
+
+		_, err := d.webhookRepo.CreateDelivery(models.WebhookDelivery{
+			EndpointID: endpoint.ID,
+			Event:      eventType,
+			Payload:    string(payload),
+			Status:     models.DeliveryPending,
+		})
+		if err != nil {
+			log.Printf("Failed to queue %s for webhook endpoint %d: %v", eventType, endpoint.ID, err)
+		}
+	}
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return "evt_" + hex.EncodeToString(id)
+}
+
+// NewSecret generates the signing secret of a new endpoint.
+func NewSecret() string {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return "whsec_" + hex.EncodeToString(secret)
+}
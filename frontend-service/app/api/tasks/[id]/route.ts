@@ -1,6 +1,20 @@
 import { cookies } from "next/headers";
 import { type NextRequest, NextResponse } from "next/server";
 
+// Preconditions are checked by the API service, so they are passed through
+function conditionalHeaders(
+  request: NextRequest,
+  name: string
+): Record<string, string> {
+  const value = request.headers.get(name);
+  return value ? { [name]: value } : {};
+}
+
+function etagHeaders(response: Response): Record<string, string> {
+  const etag = response.headers.get("ETag");
+  return etag ? { ETag: etag } : {};
+}
+
 export async function GET(request: NextRequest, { params }: { params: any }) {
   try {
     const { id } = await params;
@@ -18,10 +32,18 @@ export async function GET(request: NextRequest, { params }: { params: any }) {
         headers: {
           Authorization: `Bearer ${token}`,
           "Content-Type": "application/json",
+          ...conditionalHeaders(request, "If-None-Match"),
         },
       }
     );
 
+    if (response.status === 304) {
+      return new NextResponse(null, {
+        status: 304,
+        headers: etagHeaders(response),
+      });
+    }
+
     if (!response.ok) {
       const errorData = await response.json();
       return NextResponse.json(
@@ -31,7 +53,7 @@ export async function GET(request: NextRequest, { params }: { params: any }) {
     }
 
     const data = await response.json();
-    return NextResponse.json(data);
+    return NextResponse.json(data, { headers: etagHeaders(response) });
   } catch (error) {
     console.error("Error fetching task:", error);
     return NextResponse.json(
@@ -61,6 +83,7 @@ export async function PUT(request: NextRequest, { params }: { params: any }) {
         headers: {
           Authorization: `Bearer ${token}`,
           "Content-Type": "application/json",
+          ...conditionalHeaders(request, "If-Match"),
         },
         body: JSON.stringify(body),
       }
@@ -75,7 +98,7 @@ export async function PUT(request: NextRequest, { params }: { params: any }) {
     }
 
     const data = await response.json();
-    return NextResponse.json(data);
+    return NextResponse.json(data, { headers: etagHeaders(response) });
   } catch (error) {
     console.error("Error updating task:", error);
     return NextResponse.json(
@@ -106,6 +129,7 @@ export async function DELETE(
         headers: {
           Authorization: `Bearer ${token}`,
           "Content-Type": "application/json",
+          ...conditionalHeaders(request, "If-Match"),
         },
       }
     );
@@ -11,6 +11,9 @@ import CreateTaskDialog from "@/components/create-task-dialog";
 import type { Task, TaskFilter } from "@/types/task";
 import { Loader2, Plus } from "lucide-react";
 
+// Matches the ETag the API service sends for a task
+const taskETag = (task: Task) => `"${task.id}-${task.version}"`;
+
 export default function DashboardPage() {
   const [tasks, setTasks] = useState<Task[]>([]);
   const [isLoading, setIsLoading] = useState(true);
@@ -33,7 +36,7 @@ export default function DashboardPage() {
 
       // Build query string from filters
       const queryParams = new URLSearchParams();
-      if (filters.status) queryParams.append("status", filters.status);
+      if (filters.category) queryParams.append("category", filters.category);
       if (filters.priority) queryParams.append("priority", filters.priority);
       if (filters.tagName) queryParams.append("tagName", filters.tagName);
       if (filters.dueDateBefore)
@@ -41,22 +44,31 @@ export default function DashboardPage() {
       if (filters.dueDateAfter)
         queryParams.append("due_date_after", filters.dueDateAfter);
 
-      const queryString = queryParams.toString();
-      const url = `/api/tasks${queryString ? `?${queryString}` : ""}`;
+      // The API returns a page at a time; follow next_cursor until the
+      // last page so that the dashboard shows every task
+      const transformedTasks: Task[] = [];
+      let cursor = "";
+      do {
+        if (cursor) queryParams.set("cursor", cursor);
+        const queryString = queryParams.toString();
+        const url = `/api/tasks${queryString ? `?${queryString}` : ""}`;
 
-      const response = await fetch(url);
+        const response = await fetch(url);
 
-      if (!response.ok) {
-        const errorData = await response.json();
-        throw new Error(errorData.error || "Failed to fetch tasks");
-      }
+        if (!response.ok) {
+          const errorData = await response.json();
+          throw new Error(errorData.error || "Failed to fetch tasks");
+        }
 
-      const data = await response.json();
+        const data = await response.json();
 
-      // Transform the data structure
-      const transformedTasks = Array.isArray(data)
-        ? data.map((item) => item.task || item)
-        : [];
+        // Transform the data structure
+        const items = Array.isArray(data) ? data : data.tasks;
+        if (Array.isArray(items)) {
+          transformedTasks.push(...items.map((item) => item.task || item));
+        }
+        cursor = Array.isArray(data) ? "" : data.next_cursor || "";
+      } while (cursor);
 
       setTasks(transformedTasks);
     } catch (error) {
@@ -109,17 +121,28 @@ export default function DashboardPage() {
         method: "PUT",
         headers: {
           "Content-Type": "application/json",
+          "If-Match": taskETag(updatedTask),
         },
         body: JSON.stringify(updatedTask),
       });
 
       if (!response.ok) {
         const errorData = await response.json();
+        if (response.status === 412) {
+          fetchTasks();
+          throw new Error(
+            "This task was changed elsewhere. It has been reloaded, please try again."
+          );
+        }
         throw new Error(errorData.error || "Failed to update task");
       }
 
+      const data = await response.json();
+      // Keep the new version so that the next edit passes If-Match
+      const savedTask = { ...(data.task || data), tags: data.tags || [] };
+
       setTasks(
-        tasks.map((task) => (task.id === updatedTask.id ? updatedTask : task))
+        tasks.map((task) => (task.id === updatedTask.id ? savedTask : task))
       );
       toast("Your task has been updated successfully");
     } catch (error) {
@@ -134,17 +157,25 @@ export default function DashboardPage() {
 
   const handleDeleteTask = async (taskId: number) => {
     try {
+      const task = tasks.find((task) => task.id === taskId);
       const response = await fetch(`/api/tasks/${taskId}`, {
         method: "DELETE",
+        headers: task ? { "If-Match": taskETag(task) } : {},
       });
 
       if (!response.ok) {
         const errorData = await response.json();
+        if (response.status === 412) {
+          fetchTasks();
+          throw new Error(
+            "This task was changed elsewhere. It has been reloaded, please try again."
+          );
+        }
         throw new Error(errorData.error || "Failed to delete task");
       }
 
       setTasks(tasks.filter((task) => task.id !== taskId));
-      toast("Your task has been deleted successfully");
+      toast("Your task has been moved to the trash");
     } catch (error) {
       console.error("Error deleting task:", error);
       toast.error(
@@ -3,7 +3,7 @@
 import type React from "react";
 
 import { useState, useEffect } from "react";
-import type { Task } from "@/types/task";
+import type { Task, Workflow } from "@/types/task";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
@@ -33,8 +33,12 @@ import { CalendarIcon, Loader2, Plus, X } from "lucide-react";
 import { format } from "date-fns";
 import { cn } from "@/lib/utils";
 import { Badge } from "@/components/ui/badge";
+import { DEFAULT_WORKFLOW, statusLabel } from "@/lib/workflow";
 
 interface CreateTaskDialogProps {
+  // The workflow of the project the task goes in, the default one outside
+  // projects
+  workflow?: Workflow;
   open: boolean;
   onOpenChange: (open: boolean) => void;
   onCreateTask: (task: Partial<Task>) => void;
@@ -46,13 +50,14 @@ interface Tag {
 }
 
 export default function CreateTaskDialog({
+  workflow = DEFAULT_WORKFLOW,
   open,
   onOpenChange,
   onCreateTask,
 }: CreateTaskDialogProps) {
   const [title, setTitle] = useState("");
   const [description, setDescription] = useState("");
-  const [status, setStatus] = useState<string>("TODO");
+  const [status, setStatus] = useState<string>(workflow.statuses[0].name);
   const [priority, setPriority] = useState<string>("MEDIUM");
   const [dueDate, setDueDate] = useState<Date | undefined>(undefined);
   const [tags, setTags] = useState<Tag[]>([]);
@@ -103,7 +108,7 @@ export default function CreateTaskDialog({
   const resetForm = () => {
     setTitle("");
     setDescription("");
-    setStatus("TODO");
+    setStatus(workflow.statuses[0].name);
     setPriority("MEDIUM");
     setDueDate(undefined);
     setTags([]);
@@ -180,9 +185,11 @@ export default function CreateTaskDialog({
                     <SelectValue placeholder="Select status" />
                   </SelectTrigger>
                   <SelectContent>
-                    <SelectItem value="TODO">To Do</SelectItem>
-                    <SelectItem value="IN_PROGRESS">In Progress</SelectItem>
-                    <SelectItem value="COMPLETED">Completed</SelectItem>
+                    {workflow.statuses.map((candidate) => (
+                      <SelectItem key={candidate.name} value={candidate.name}>
+                        {statusLabel(candidate.name)}
+                      </SelectItem>
+                    ))}
                   </SelectContent>
                 </Select>
               </div>
@@ -3,7 +3,7 @@
 import type React from "react";
 
 import { useState, useEffect } from "react";
-import type { Task } from "@/types/task";
+import type { Task, Workflow } from "@/types/task";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
@@ -33,9 +33,11 @@ import { CalendarIcon, Loader2, Plus, X } from "lucide-react";
 import { format } from "date-fns";
 import { cn } from "@/lib/utils";
 import { Badge } from "@/components/ui/badge";
+import { allowedStatuses, statusLabel } from "@/lib/workflow";
 
 interface EditTaskDialogProps {
   task: Task;
+  workflow: Workflow;
   open: boolean;
   onOpenChange: (open: boolean) => void;
   onUpdateTask: (task: Task) => void;
@@ -48,6 +50,7 @@ interface Tag {
 
 export default function EditTaskDialog({
   task,
+  workflow,
   open,
   onOpenChange,
   onUpdateTask,
@@ -64,6 +67,9 @@ export default function EditTaskDialog({
   const [newTagName, setNewTagName] = useState("");
   const [isLoadingTags, setIsLoadingTags] = useState(false);
 
+  // Only the statuses the workflow lets the task move to are offered
+  const statuses = allowedStatuses(workflow, task.status);
+
   useEffect(() => {
     if (open) {
       fetchTags();
@@ -106,6 +112,9 @@ export default function EditTaskDialog({
       title,
       description,
       status,
+      status_category:
+        statuses.find((candidate) => candidate.name === status)?.category ||
+        task.status_category,
       priority,
       due_date: dueDate ? format(dueDate, "yyyy-MM-dd'T'HH:mm:ss") : null,
       tags: tags.map((tag) => ({ id: tag.id, name: tag.name })),
@@ -185,9 +194,11 @@ export default function EditTaskDialog({
                     <SelectValue placeholder="Select status" />
                   </SelectTrigger>
                   <SelectContent>
-                    <SelectItem value="TODO">To Do</SelectItem>
-                    <SelectItem value="IN_PROGRESS">In Progress</SelectItem>
-                    <SelectItem value="COMPLETED">Completed</SelectItem>
+                    {statuses.map((candidate) => (
+                      <SelectItem key={candidate.name} value={candidate.name}>
+                        {statusLabel(candidate.name)}
+                      </SelectItem>
+                    ))}
                   </SelectContent>
                 </Select>
               </div>
@@ -1,7 +1,7 @@
 "use client"
 
 import { useEffect, useState } from "react"
-import type { TaskFilter } from "@/types/task"
+import type { StatusCategory, TaskFilter } from "@/types/task"
 import { Button } from "@/components/ui/button"
 import { Card, CardContent } from "@/components/ui/card"
 import { Label } from "@/components/ui/label"
@@ -11,6 +11,7 @@ import { Calendar } from "@/components/ui/calendar"
 import { CalendarIcon, FilterX } from "lucide-react"
 import { format } from "date-fns"
 import { cn } from "@/lib/utils"
+import { CATEGORY_LABELS } from "@/lib/workflow"
 
 interface TaskFiltersProps {
   filters: TaskFilter
@@ -50,8 +51,12 @@ export default function TaskFilters({ filters, setFilters }: TaskFiltersProps) {
     fetchTags()
   }, [])
 
-  const handleStatusChange = (value: string) => {
-    setFilters({ ...filters, status: value || undefined })
+  // Statuses differ between projects, so tasks are filtered by category
+  const handleCategoryChange = (value: string) => {
+    setFilters({
+      ...filters,
+      category: value && value !== "ALL" ? (value as StatusCategory) : undefined,
+    })
   }
 
   const handlePriorityChange = (value: string) => {
@@ -91,16 +96,18 @@ export default function TaskFilters({ filters, setFilters }: TaskFiltersProps) {
       <CardContent className="p-4">
         <div className="flex flex-wrap items-end gap-4">
           <div className="space-y-1.5">
-            <Label htmlFor="status">Status</Label>
-            <Select value={filters.status || ""} onValueChange={handleStatusChange}>
-              <SelectTrigger id="status" className="w-[180px]">
+            <Label htmlFor="category">Status</Label>
+            <Select value={filters.category || ""} onValueChange={handleCategoryChange}>
+              <SelectTrigger id="category" className="w-[180px]">
                 <SelectValue placeholder="All statuses" />
               </SelectTrigger>
               <SelectContent>
                 <SelectItem value="ALL">All statuses</SelectItem>
-                <SelectItem value="TODO">To Do</SelectItem>
-                <SelectItem value="IN_PROGRESS">In Progress</SelectItem>
-                <SelectItem value="COMPLETED">Completed</SelectItem>
+                {Object.entries(CATEGORY_LABELS).map(([category, label]) => (
+                  <SelectItem key={category} value={category}>
+                    {label}
+                  </SelectItem>
+                ))}
               </SelectContent>
             </Select>
           </div>
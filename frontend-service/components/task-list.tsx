@@ -1,7 +1,7 @@
 "use client";
 
 import { useState } from "react";
-import type { Task } from "@/types/task";
+import type { StatusCategory, Task } from "@/types/task";
 import {
   Card,
   CardContent,
@@ -16,6 +16,7 @@ import { Checkbox } from "@/components/ui/checkbox";
 import { Calendar, Edit, Trash2 } from "lucide-react";
 import { format } from "date-fns";
 import EditTaskDialog from "./edit-task-dialog";
+import { statusIn, statusLabel, useWorkflows } from "@/lib/workflow";
 import {
   AlertDialog,
   AlertDialogAction,
@@ -40,10 +41,19 @@ export default function TaskList({
   onDeleteTask,
 }: TaskListProps) {
   const [editingTask, setEditingTask] = useState<Task | null>(null);
+  const workflowOf = useWorkflows(tasks.map((task) => task.project_id));
 
+  // Completing takes the first done status of the task's workflow
   const handleStatusChange = (task: Task, completed: boolean) => {
-    const newStatus = completed ? "COMPLETED" : "TODO";
-    onUpdateTask({ ...task, status: newStatus });
+    const workflow = workflowOf(task.project_id);
+    const newStatus = completed
+      ? statusIn(workflow, "done")
+      : workflow.statuses[0];
+    onUpdateTask({
+      ...task,
+      status: newStatus.name,
+      status_category: newStatus.category,
+    });
   };
 
   const getPriorityColor = (priority: string) => {
@@ -59,13 +69,13 @@ export default function TaskList({
     }
   };
 
-  const getStatusColor = (status: string) => {
-    switch (status) {
-      case "COMPLETED":
+  const getStatusColor = (category: StatusCategory) => {
+    switch (category) {
+      case "done":
         return "bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-300";
-      case "IN_PROGRESS":
+      case "doing":
         return "bg-blue-100 text-blue-800 dark:bg-blue-900 dark:text-blue-300";
-      case "TODO":
+      case "todo":
         return "bg-gray-100 text-gray-800 dark:bg-gray-800 dark:text-gray-300";
       default:
         return "bg-gray-100 text-gray-800 dark:bg-gray-800 dark:text-gray-300";
@@ -90,7 +100,7 @@ export default function TaskList({
             <div className="flex items-start justify-between">
               <div className="flex items-start gap-2">
                 <Checkbox
-                  checked={task.status === "COMPLETED"}
+                  checked={task.status_category === "done"}
                   onCheckedChange={(checked) =>
                     handleStatusChange(task, checked as boolean)
                   }
@@ -98,7 +108,7 @@ export default function TaskList({
                 />
                 <CardTitle
                   className={
-                    task.status === "COMPLETED"
+                    task.status_category === "done"
                       ? "line-through text-muted-foreground"
                       : ""
                   }
@@ -111,9 +121,12 @@ export default function TaskList({
               </Badge>
             </div>
             <CardDescription className="mt-2">
-              <Badge variant="outline" className={getStatusColor(task.status)}>
+              <Badge
+                variant="outline"
+                className={getStatusColor(task.status_category)}
+              >
                 {task.status && typeof task.status === "string"
-                  ? task.status.replace("_", " ")
+                  ? statusLabel(task.status)
                   : "Unknown"}
               </Badge>
             </CardDescription>
@@ -183,6 +196,7 @@ export default function TaskList({
       {editingTask && (
         <EditTaskDialog
           task={editingTask}
+          workflow={workflowOf(editingTask.project_id)}
           open={!!editingTask}
           onOpenChange={(open) => !open && setEditingTask(null)}
           onUpdateTask={onUpdateTask}
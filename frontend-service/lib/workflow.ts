@@ -0,0 +1,93 @@
+import { useEffect, useState } from "react";
+import type { StatusCategory, Workflow, WorkflowStatus } from "@/types/task";
+
+// Tasks outside projects follow the API service's default workflow, which has
+// no endpoint of its own
+export const DEFAULT_WORKFLOW: Workflow = {
+  custom: false,
+  statuses: [
+    { name: "TODO", category: "todo" },
+    { name: "IN_PROGRESS", category: "doing" },
+    { name: "COMPLETED", category: "done" },
+  ],
+  transitions: [],
+};
+
+export const CATEGORY_LABELS: Record<StatusCategory, string> = {
+  todo: "To Do",
+  doing: "In Progress",
+  done: "Done",
+};
+
+const sameStatus = (a: string, b: string) =>
+  a.toLowerCase() === b.toLowerCase();
+
+// The first status of a category, like Workflow.Fit in the API service
+export function statusIn(
+  workflow: Workflow,
+  category: StatusCategory
+): WorkflowStatus {
+  return (
+    workflow.statuses.find((status) => status.category === category) ||
+    workflow.statuses[0]
+  );
+}
+
+// The statuses a task in status may move to, itself included
+export function allowedStatuses(
+  workflow: Workflow,
+  status: string
+): WorkflowStatus[] {
+  if (workflow.transitions.length === 0) {
+    return workflow.statuses;
+  }
+  return workflow.statuses.filter(
+    (candidate) =>
+      sameStatus(candidate.name, status) ||
+      workflow.transitions.some(
+        (transition) =>
+          sameStatus(transition.from, status) &&
+          sameStatus(transition.to, candidate.name)
+      )
+  );
+}
+
+export const statusLabel = (status: string) => status.replace(/_/g, " ");
+
+// Loads the workflows of the given projects and returns a lookup that falls
+// back to the default workflow until a project's has arrived
+export function useWorkflows(projectIds: (number | null | undefined)[]) {
+  const [workflows, setWorkflows] = useState<Record<number, Workflow>>({});
+  const wanted = Array.from(
+    new Set(projectIds.filter((id): id is number => id != null))
+  ).sort((a, b) => a - b);
+  const key = wanted.join(",");
+
+  useEffect(() => {
+    const missing = wanted.filter((id) => !(id in workflows));
+    if (missing.length === 0) return;
+
+    const fetchWorkflows = async () => {
+      const loaded: Record<number, Workflow> = {};
+      for (const id of missing) {
+        try {
+          const response = await fetch(`/api/projects/${id}/workflow`);
+
+          if (!response.ok) {
+            throw new Error("Failed to fetch workflow");
+          }
+
+          loaded[id] = await response.json();
+        } catch (error) {
+          console.error("Error fetching workflow:", error);
+        }
+      }
+      setWorkflows((current) => ({ ...current, ...loaded }));
+    };
+
+    fetchWorkflows();
+  }, [key]);
+
+  return (projectId: number | null | undefined): Workflow =>
+    (projectId != null && workflows[projectId]) || DEFAULT_WORKFLOW;
+}
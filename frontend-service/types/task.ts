@@ -3,9 +3,12 @@ export interface Task {
   title: string
   description: string | null
   status: string
+  status_category: StatusCategory
+  project_id?: number | null
   due_date: string | null
   priority: string
   user_id: number
+  version: number
   created_at?: string
   updated_at?: string
   tags: Tag[]
@@ -16,8 +19,26 @@ export interface Tag {
   name: string
 }
 
+export type StatusCategory = "todo" | "doing" | "done"
+
+export interface WorkflowStatus {
+  name: string
+  category: StatusCategory
+}
+
+export interface WorkflowTransition {
+  from: string
+  to: string
+}
+
+export interface Workflow {
+  custom: boolean
+  statuses: WorkflowStatus[]
+  transitions: WorkflowTransition[]
+}
+
 export interface TaskFilter {
-  status?: string
+  category?: StatusCategory
   priority?: string
   dueDateBefore?: string
   dueDateAfter?: string